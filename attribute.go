@@ -16,12 +16,96 @@ import (
 	"strings"
 )
 
+//
+// Defines how the value of an attribute is written in markup.
+//
+type AttributeValueStyle uint32
+
+// Enumeration
+const (
+	// The value is wrapped in double quotes, such as `name="value"`.
+	QuotedValue AttributeValueStyle = iota
+
+	// The value is wrapped in braces, such as `name={value}` in JSX.
+	// The value excludes the braces.
+	BracedValue
+
+	// The attribute has no value, such as `disabled`.
+	NoValue
+
+	// A spread attribute, such as `{...props}` in JSX, which has no
+	// name. The value excludes the braces and the leading dots.
+	SpreadValue
+
+	// The value is wrapped in single quotes, such as `name='value'`.
+	SingleQuotedValue
+
+	// The value is not quoted, such as `name=value`.
+	UnquotedValue
+)
+
 //
 // Holds the values for an attribute pair.
 //
 type HtmlAttribute struct {
-	Name  string // the name of this attribute
-	Value string // the value of this attribute
+	Name          string              // the name of this attribute
+	Value         string              // the value of this attribute
+	ValueStyle    AttributeValueStyle // how the value is written in markup
+	_rawName      string              // the name as spelled in source
+	_nameRange    Range               // where the name is in source
+	_valueRange   Range               // where the value is in source
+	_rawValue     string              // the value as spelled in source
+	_decodedValue string              // the value with character references decoded
+	_options      *ParseOptions       // the options of the node when the attribute was added
+	_owner        *HtmlNode           // the node the attribute was added to
+}
+
+//
+// Return the name of this attribute as it was spelled in source,
+// such as `onClick`. For attributes that were not parsed from
+// source, or whose `Name` was changed to another name, this is the
+// same as `Name`.
+//
+func (attr *HtmlAttribute) RawName() string {
+	if attr._rawName == "" || !strings.EqualFold(attr._rawName, attr.Name) {
+		return attr.Name
+	}
+
+	return attr._rawName
+}
+
+//
+// Check if this attribute has a value, which is not the case for
+// boolean attributes such as `<input disabled>`. An attribute with
+// an empty value, such as `disabled=""`, does have a value.
+//
+func (attr *HtmlAttribute) HasValue() bool {
+	return attr.ValueStyle != NoValue || attr.Value != ""
+}
+
+//
+// Return the normalized, lower-cased, name of this attribute.
+//
+func (attr *HtmlAttribute) NormalizedName() string {
+	return strings.ToLower(attr.Name)
+}
+
+//
+// Return where the name of this attribute is in the parsed
+// source. The range is not valid for attributes that were not
+// parsed from source.
+//
+func (attr *HtmlAttribute) NameRange() Range {
+	return attr._nameRange
+}
+
+//
+// Return where the value of this attribute, excluding any quotes,
+// is in the parsed source. The range is not valid for attributes
+// that were not parsed from source, or that have no value.
+//
+func (attr *HtmlAttribute) ValueRange() Range {
+	return attr._valueRange
 }
 
 func (node *HtmlNode) NumAttributes() int {
@@ -48,13 +132,23 @@ func (node *HtmlNode) ContainsAttributes() bool {
 // merge them into a single value.
 //
 func (node *HtmlNode) AddAttribute(key string, value string) {
-	if len(node.Attributes) == 0 {
-		node.Attributes = make([]*HtmlAttribute, 0)
-	}
+	node.addAttribute(&HtmlAttribute{
+		Name:     key,
+		Value:    value,
+		_options: node._options,
+	})
+}
 
-	node.Attributes = append(node.Attributes, &HtmlAttribute{
-		Name:  key,
-		Value: value,
+//
+// Add a new boolean attribute, such as `disabled`, that has no
+// value to this node. See `AddAttribute()` for how attributes with
+// the same name are handled.
+//
+func (node *HtmlNode) AddBooleanAttribute(key string) {
+	node.addAttribute(&HtmlAttribute{
+		Name:       key,
+		ValueStyle: NoValue,
+		_options:   node._options,
 	})
 }
 
@@ -80,7 +174,7 @@ func (node *HtmlNode) GetAttribute(key string) *HtmlAttribute {
 	}
 
 	for _, attr := range node.Attributes {
-		if strings.EqualFold(attr.Name, key) {
+		if node.attributeNameMatches(attr.Name, key) {
 			return attr
 		}
 	}
@@ -89,17 +183,12 @@ func (node *HtmlNode) GetAttribute(key string) *HtmlAttribute {
 }
 
 func (node *HtmlNode) GetAttributeValue(key string) (string, error) {
-	if !node.ContainsAttributes() {
+	attr := node.GetAttribute(key)
+	if attr == nil {
 		return "", errors.New("Attribute '" + key + "' is not defined")
 	}
 
-	for _, attr := range node.Attributes {
-		if strings.EqualFold(attr.Name, key) {
-			return attr.Value, nil
-		}
-	}
-
-	return "", errors.New("Attribute '" + key + "' is not defined")
+	return attr.Value, nil
 }
 
 func (node *HtmlNode) RemoveAttribute(key string) bool {
@@ -110,7 +199,7 @@ func (node *HtmlNode) RemoveAttribute(key string) bool {
 	modified := false
 	newAttributes := make([]*HtmlAttribute, 0)
 	for _, attr := range node.Attributes {
-		if strings.EqualFold(attr.Name, key) {
+		if node.attributeNameMatches(attr.Name, key) {
 			modified = true
 			continue
 		}
@@ -124,27 +213,40 @@ func (node *HtmlNode) RemoveAttribute(key string) bool {
 	return modified
 }
 
+//
+// Set the value of the attribute with the given name, adding
+// the attribute if it does not exist. If the node was parsed
+// with options that do not allow multiple attributes with the
+// same name, all other attributes with this name are removed.
+//
 func (node *HtmlNode) SetAttribute(key string, value string) bool {
-	if node.ContainsAttributes() {
-		// do we have the attribute with matching name?
-		for _, attr := range node.Attributes {
-			if strings.EqualFold(attr.Name, key) {
-				attr.Value = value
-				return true
-			}
-		}
+	attr := node.GetAttribute(key)
+	if attr == nil {
+		node.Attributes = append(node.Attributes, &HtmlAttribute{
+			Name:     key,
+			Value:    value,
+			_options: node._options,
+			_owner:   node,
+		})
+
+		return true
 	}
 
-	// no attributes in node, add one
-	if node.Attributes == nil {
-		node.Attributes = make([]*HtmlAttribute, 0)
+	attr.Value = value
+	if node.options().AllowMultipleAttributesWithSameName {
+		return true
 	}
 
-	node.Attributes = append(node.Attributes, &HtmlAttribute{
-		Name:  key,
-		Value: value,
-	})
+	// drop all other attributes with the same name
+	newAttributes := make([]*HtmlAttribute, 0, len(node.Attributes))
+	for _, other := range node.Attributes {
+		if other != attr && node.attributeNameMatches(other.Name, key) {
+			continue
+		}
+		newAttributes = append(newAttributes, other)
+	}
 
+	node.Attributes = newAttributes
 	return true
 }
 
@@ -160,7 +262,7 @@ func (node *HtmlNode) GetAttributes(key string) []*HtmlAttribute {
 
 	result := make([]*HtmlAttribute, 0)
 	for _, attr := range node.Attributes {
-		if strings.EqualFold(attr.Name, key) {
+		if node.attributeNameMatches(attr.Name, key) {
 			result = append(result, attr)
 		}
 	}
@@ -170,27 +272,35 @@ func (node *HtmlNode) GetAttributes(key string) []*HtmlAttribute {
 
 //
 // Function removes all duplicate attributes from the node.
-// The first available value is kept and other values are
-// dropped. The function returns `true` if the attributes
-// were modified (duplicates were removed), `false` otherwise.
+// The values of the duplicates are merged into the first
+// attribute with that name using the `DuplicateAttributePolicy`
+// of the options the node was parsed with, which by default
+// keeps the first available value. The function returns `true`
+// if the attributes were modified (duplicates were removed),
+// `false` otherwise.
 //
 func (node *HtmlNode) RemoveDuplicateAttributes() bool {
 	if !node.ContainsAttributes() {
 		return false
 	}
 
-	attributeMap := make(map[string]*HtmlAttribute, 0)
+	policy := node.options().DuplicateAttributePolicy
+	result := make([]*HtmlAttribute, 0, len(node.Attributes))
+	attributeMap := make(map[string]*HtmlAttribute, len(node.Attributes))
 	for _, attr := range node.Attributes {
-		attributeMap[attr.Name] = attr
-	}
+		key := node.attributeKey(attr.Name)
+		existing, found := attributeMap[key]
+		if !found {
+			attributeMap[key] = attr
+			result = append(result, attr)
+			continue
+		}
 
-	if len(attributeMap) == len(node.Attributes) {
-		return false
+		existing.merge(policy, attr)
 	}
 
-	result := make([]*HtmlAttribute, 0)
-	for _, value := range attributeMap {
-		result = append(result, value)
+	if len(result) == len(node.Attributes) {
+		return false
 	}
 
 	node.Attributes = result
@@ -208,10 +318,164 @@ func (node *HtmlNode) GetAttributeWithValue(key string, value string) *HtmlAttri
 	}
 
 	for _, attr := range node.Attributes {
-		if attr.Name == key && attr.Value == value {
+		if attr.Value == value && node.attributeNameMatches(attr.Name, key) {
 			return attr
 		}
 	}
 
 	return nil
 }
+
+//----- Internal methods
+
+//
+// Add the given attribute to this node, after any existing
+// attribute with the same name.
+//
+func (node *HtmlNode) addAttribute(attr *HtmlAttribute) {
+	attr._owner = node
+	node.Attributes = append(node.Attributes, attr)
+}
+
+//
+// Return the options of the node when this attribute was added,
+// or the default options.
+//
+func (attr *HtmlAttribute) options() *ParseOptions {
+	if attr._options == nil {
+		return getDefaultOptions()
+	}
+
+	return attr._options
+}
+
+//
+// Set the given value on the first attribute with the given name,
+// adding it if it does not exist, and remove all other attributes
+// with the same name. The value is written in double quotes, as it
+// is no longer code such as that of a braced value.
+//
+func (node *HtmlNode) mergeAttributes(name string, value string) {
+	var first *HtmlAttribute
+	newAttributes := make([]*HtmlAttribute, 0, len(node.Attributes)+1)
+	for _, attr := range node.Attributes {
+		if !node.attributeNameMatches(attr.Name, name) {
+			newAttributes = append(newAttributes, attr)
+			continue
+		}
+
+		if first == nil {
+			first = attr
+			newAttributes = append(newAttributes, attr)
+		}
+	}
+
+	if first == nil {
+		first = &HtmlAttribute{
+			Name:     name,
+			_options: node._options,
+			_owner:   node,
+		}
+		newAttributes = append(newAttributes, first)
+	}
+
+	first.Value = value
+	first.ValueStyle = QuotedValue
+	node.Attributes = newAttributes
+}
+
+//
+// Check if the given attribute names match as per the options
+// this node was parsed with.
+//
+func (node *HtmlNode) attributeNameMatches(name string, key string) bool {
+	if node.options().CaseSensitiveAttributes {
+		return name == key
+	}
+
+	return strings.EqualFold(name, key)
+}
+
+//
+// Return the key under which the attribute name is considered
+// unique as per the options this node was parsed with.
+//
+func (node *HtmlNode) attributeKey(name string) string {
+	if node.options().CaseSensitiveAttributes {
+		return name
+	}
+
+	return strings.ToLower(name)
+}
+
+//
+// Merge the value of the given duplicate attribute into this
+// attribute as per the given policy. The value this attribute was
+// parsed with no more applies once the value changes: the last
+// attribute brings its own, while concatenated values have none.
+//
+func (attr *HtmlAttribute) merge(policy DuplicateAttributePolicy, duplicate *HtmlAttribute) {
+	value := mergeAttributeValues(policy, attr.Name, attr.Value, duplicate.Value)
+	switch {
+	case policy == LastAttributeWins:
+		attr.Value = duplicate.Value
+		attr.ValueStyle = duplicate.ValueStyle
+		attr._rawValue = duplicate._rawValue
+		attr._decodedValue = duplicate._decodedValue
+		attr._valueRange = duplicate._valueRange
+
+	case value != attr.Value:
+		attr.Value = value
+		attr.ValueStyle = QuotedValue
+		attr._rawValue = ""
+		attr._decodedValue = ""
+		attr._valueRange = Range{}
+	}
+}
+
+//
+// Merge the value of a duplicate attribute into the existing
+// value as per the given policy.
+//
+func mergeAttributeValues(policy DuplicateAttributePolicy, name string, existing string, value string) string {
+	switch policy {
+	case LastAttributeWins:
+		return value
+
+	case ConcatenateAttributeValues:
+		return concatenateAttributeValues(name, existing, value)
+	}
+
+	return existing
+}
+
+//
+// Concatenate the values of `class` and `style` attributes. For
+// all other attributes the existing value is returned.
+//
+func concatenateAttributeValues(name string, existing string, value string) string {
+	var separator string
+	switch strings.ToLower(name) {
+	case "class":
+		separator = " "
+
+	case "style":
+		separator = "; "
+		existing = strings.TrimRight(existing, whitespace+";")
+
+	default:
+		return existing
+	}
+
+	existing = strings.TrimSpace(existing)
+	value = strings.TrimSpace(value)
+	if existing == "" {
+		return value
+	}
+
+	if value == "" {
+		return existing
+	}
+
+	return existing + separator + value
+}
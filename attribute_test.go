@@ -25,12 +25,13 @@ func getDoc(html string) (*HtmlElements, error) {
 
 func getAttributeDoc() (*HtmlElements, error) {
 	html := "<html class='a1' class='b1' class='c1'>Hello World</html>"
-	reader := strings.NewReader(html)
-	return ParseHtml(reader)
+	options := getDefaultOptions()
+	options.AllowMultipleAttributesWithSameName = true
+	return ParseWithOptions(strings.NewReader(html), options)
 }
 
 func TestAttributes(t *testing.T) {
-	elements, err := ParseHtmlString("<html class='a1' class='b1' class='c1'>Hello World</html>")
+	elements, err := getAttributeDoc()
 	assert.NoError(t, err)
 
 	assert.Equal(t, 1, elements.Length())              // html node
@@ -61,3 +62,205 @@ func TestEmptyAttributes(t *testing.T) {
 	assert.Nil(t, node.GetAttributes("class"))
 	assert.Nil(t, node.GetAttributeWithValue("class", "b1"))
 }
+
+func parseWithAttributeOptions(t *testing.T, html string, caseSensitive bool, allowMultiple bool, policy DuplicateAttributePolicy) *HtmlNode {
+	options := getDefaultOptions()
+	options.CaseSensitiveAttributes = caseSensitive
+	options.AllowMultipleAttributesWithSameName = allowMultiple
+	options.DuplicateAttributePolicy = policy
+
+	elements, err := ParseWithOptions(strings.NewReader(html), options)
+	assert.NoError(t, err)
+	return elements.First()
+}
+
+func TestDisallowMultipleAttributes(t *testing.T) {
+	html := "<div class='a1' style='color: red;' class='b1' id='x' style='margin: 0' id='y'></div>"
+
+	node := parseWithAttributeOptions(t, html, false, false, FirstAttributeWins)
+	assert.Equal(t, 3, node.NumAttributes())
+	assert.Equal(t, "a1", node.GetAttribute("class").Value)
+	assert.Equal(t, "x", node.GetAttribute("id").Value)
+
+	node = parseWithAttributeOptions(t, html, false, false, LastAttributeWins)
+	assert.Equal(t, 3, node.NumAttributes())
+	assert.Equal(t, "b1", node.GetAttribute("class").Value)
+	assert.Equal(t, "margin: 0", node.GetAttribute("style").Value)
+	assert.Equal(t, "y", node.GetAttribute("id").Value)
+
+	node = parseWithAttributeOptions(t, html, false, false, ConcatenateAttributeValues)
+	assert.Equal(t, 3, node.NumAttributes())
+	assert.Equal(t, "a1 b1", node.GetAttribute("class").Value)
+	assert.Equal(t, "color: red; margin: 0", node.GetAttribute("style").Value)
+	assert.Equal(t, "x", node.GetAttribute("id").Value)
+
+	// adding keeps both attributes, and they can be merged later
+	node.AddAttribute("CLASS", "c1")
+	assert.Equal(t, 2, len(node.GetAttributes("class")))
+	assert.True(t, node.RemoveDuplicateAttributes())
+	assert.Equal(t, "a1 b1 c1", node.GetAttribute("class").Value)
+}
+
+func TestAddAttributeKeepsAllValues(t *testing.T) {
+	node := NewElement("div")
+	node.AddAttribute("class", "a")
+	node.AddAttribute("class", "b")
+	assert.Equal(t, 2, node.NumAttributes())
+	assert.Equal(t, "a", node.GetAttribute("class").Value)
+	assert.Equal(t, `<div class="a" class="b"></div>`, node.String())
+
+	// nodes parsed with the default options
+	doc, err := getDoc("<div class='a'></div>")
+	assert.NoError(t, err)
+	node = doc.First()
+	node.AddAttribute("class", "b")
+	assert.Equal(t, 2, len(node.GetAttributes("class")))
+}
+
+func TestCaseSensitiveAttributes(t *testing.T) {
+	node := parseWithAttributeOptions(t, "<Comp onClick='a' onclick='b' Value='c'></Comp>", true, true, FirstAttributeWins)
+	assert.Equal(t, 3, node.NumAttributes())
+	assert.Equal(t, "onClick", node.Attributes[0].Name)
+	assert.Equal(t, "a", node.GetAttribute("onClick").Value)
+	assert.Equal(t, "b", node.GetAttribute("onclick").Value)
+	assert.Nil(t, node.GetAttribute("value"))
+	assert.Equal(t, 1, len(node.GetAttributes("onClick")))
+	assert.NotNil(t, node.GetAttributeWithValue("Value", "c"))
+	assert.Nil(t, node.GetAttributeWithValue("value", "c"))
+
+	_, err := node.GetAttributeValue("VALUE")
+	assert.Error(t, err)
+
+	assert.False(t, node.RemoveAttribute("ONCLICK"))
+	assert.True(t, node.RemoveAttribute("onclick"))
+	assert.Equal(t, 2, node.NumAttributes())
+	assert.False(t, node.RemoveDuplicateAttributes())
+
+	// case-insensitive by default
+	node = parseWithAttributeOptions(t, "<Comp onClick='a'></Comp>", false, true, FirstAttributeWins)
+	assert.Equal(t, "onclick", node.Attributes[0].Name)
+	assert.NotNil(t, node.GetAttribute("ONCLICK"))
+}
+
+func TestSetAttribute(t *testing.T) {
+	node := parseWithAttributeOptions(t, "<div class='a1' class='b1'></div>", false, true, FirstAttributeWins)
+	assert.True(t, node.SetAttribute("CLASS", "c1"))
+	assert.Equal(t, 2, len(node.GetAttributes("class")))
+	assert.Equal(t, "c1", node.GetAttribute("class").Value)
+
+	assert.True(t, node.SetAttribute("id", "x"))
+	assert.Equal(t, "x", node.GetAttribute("id").Value)
+
+	// disallowing duplicates removes the others
+	node = parseWithAttributeOptions(t, "<div class='a1'></div>", false, false, FirstAttributeWins)
+	node.Attributes = append(node.Attributes, &HtmlAttribute{Name: "class", Value: "b1"})
+	assert.True(t, node.SetAttribute("class", "c1"))
+	assert.Equal(t, 1, len(node.GetAttributes("class")))
+
+	// value
+	value, err := node.GetAttributeValue("class")
+	assert.NoError(t, err)
+	assert.Equal(t, "c1", value)
+}
+
+func TestRemoveDuplicateAttributes(t *testing.T) {
+	elements, err := getAttributeDoc()
+	assert.NoError(t, err)
+
+	node := elements.First()
+	node.AddAttribute("id", "x")
+	assert.True(t, node.RemoveDuplicateAttributes())
+	assert.Equal(t, 2, node.NumAttributes())
+	assert.Equal(t, "a1", node.Attributes[0].Value)
+	assert.Equal(t, "id", node.Attributes[1].Name)
+	assert.False(t, node.RemoveDuplicateAttributes())
+
+	node = parseWithAttributeOptions(t, "<div class='a1' CLASS='b1'></div>", false, true, ConcatenateAttributeValues)
+	assert.True(t, node.RemoveDuplicateAttributes())
+	assert.Equal(t, "a1 b1", node.GetAttribute("class").Value)
+}
+
+func TestMergedAttributeSource(t *testing.T) {
+	html := "<div class=a1 class='b &amp; c' hidden hidden=y></div>"
+
+	// the last attribute brings its source along
+	node := parseWithAttributeOptions(t, html, false, false, LastAttributeWins)
+	class := node.GetAttribute("class")
+	assert.Equal(t, "b & c", class.Value)
+	assert.Equal(t, "b &amp; c", class.RawValue())
+	assert.Equal(t, SingleQuotedValue, class.ValueStyle)
+	assert.Equal(t, 21, class.ValueRange().Start.Offset)
+	assert.Equal(t, UnquotedValue, node.GetAttribute("hidden").ValueStyle)
+	assert.Equal(t, `<div class="b &amp; c" hidden="y"></div>`, node.String())
+
+	// concatenated values have no source
+	node = parseWithAttributeOptions(t, html, false, false, ConcatenateAttributeValues)
+	class = node.GetAttribute("class")
+	assert.Equal(t, "a1 b & c", class.Value)
+	assert.Equal(t, "a1 b &amp; c", class.RawValue())
+	assert.Equal(t, QuotedValue, class.ValueStyle)
+	assert.Equal(t, Range{}, class.ValueRange())
+	assert.Equal(t, NoValue, node.GetAttribute("hidden").ValueStyle)
+
+	// and the same holds when merging later
+	node = parseWithAttributeOptions(t, html, false, true, LastAttributeWins)
+	assert.True(t, node.RemoveDuplicateAttributes())
+	assert.Equal(t, 21, node.GetAttribute("class").ValueRange().Start.Offset)
+	assert.Equal(t, `<div class="b &amp; c" hidden="y"></div>`, node.String())
+}
+
+func TestRawAttributeName(t *testing.T) {
+	elements, err := ParseHtmlString("<html custom:Title='hello' onClick='x'></html>")
+	assert.NoError(t, err)
+
+	attr := elements.First().GetAttribute("custom:title")
+	assert.Equal(t, "custom:title", attr.Name)
+	assert.Equal(t, "custom:Title", attr.RawName())
+	assert.Equal(t, "custom:title", attr.NormalizedName())
+
+	// renamed attributes use the new name
+	attr.Name = "data-title"
+	assert.Equal(t, "data-title", attr.RawName())
+	assert.Equal(t, `<html data-title="hello" onClick="x"></html>`, elements.First().String())
+
+	// attributes not created by parsing
+	attr = &HtmlAttribute{Name: "onClick", Value: "x"}
+	assert.Equal(t, "onClick", attr.RawName())
+	assert.Equal(t, "onclick", attr.NormalizedName())
+}
+
+func TestBooleanAttributes(t *testing.T) {
+	html := `<input disabled value="" checked=''  name=x data-a='it"s'>`
+	doc, err := getDoc(html)
+	assert.NoError(t, err)
+
+	input := doc.First()
+	disabled := input.GetAttribute("disabled")
+	assert.False(t, disabled.HasValue())
+	assert.Equal(t, NoValue, disabled.ValueStyle)
+	assert.True(t, input.GetAttribute("value").HasValue())
+	assert.Equal(t, QuotedValue, input.GetAttribute("value").ValueStyle)
+	assert.Equal(t, SingleQuotedValue, input.GetAttribute("checked").ValueStyle)
+	assert.Equal(t, UnquotedValue, input.GetAttribute("name").ValueStyle)
+
+	// bare attributes are always written as such
+	assert.Equal(t, `<input disabled value="" checked="" name="x" data-a="it&quot;s">`, input.String())
+
+	// the quotes are kept when preserving the source
+	input.SetAttribute("name", "a b")
+	builder := strings.Builder{}
+	assert.NoError(t, input.Render(&builder, RenderOptions{PreserveSource: true}))
+	assert.Equal(t, `<input disabled value="" checked='' name="a b" data-a='it"s'>`, builder.String())
+
+	// giving a value to a boolean attribute
+	disabled.Value = "true"
+	assert.True(t, disabled.HasValue())
+	assert.Equal(t, `<input disabled="true" value="" checked="" name="a b" data-a="it&quot;s">`, input.String())
+}
+
+func TestAddBooleanAttribute(t *testing.T) {
+	node := El("custom:Toggle").BoolAttr("on").Attr("label", "").Build()
+	assert.Equal(t, "<custom:Toggle on label=\"\"></custom:Toggle>", node.String())
+	assert.False(t, node.GetAttribute("on").HasValue())
+	assert.True(t, node.HasAttribute("on"))
+}
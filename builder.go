@@ -0,0 +1,118 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+//
+// Anything that can build a node, such as an `ElementBuilder` or
+// an already created `HtmlNode`.
+//
+type NodeBuilder interface {
+	Build() *HtmlNode
+}
+
+//
+// A fluent builder to create an element along with its attributes
+// and children, such as:
+//
+//	El("div").Attr("class", "x").Children(
+//		El("b").Text("Hello"),
+//		NewText(" World"),
+//	).Build()
+//
+type ElementBuilder struct {
+	node *HtmlNode // the element being built
+}
+
+//
+// Start building a new element with the given tag name. See
+// `NewElement()` for how the name is used.
+//
+func El(name string) *ElementBuilder {
+	return &ElementBuilder{
+		node: NewElement(name),
+	}
+}
+
+//
+// Add an attribute with the given name and value to the element.
+//
+func (builder *ElementBuilder) Attr(name string, value string) *ElementBuilder {
+	builder.node.AddAttribute(name, value)
+	return builder
+}
+
+//
+// Add a boolean attribute with the given name, that has no value,
+// to the element.
+//
+func (builder *ElementBuilder) BoolAttr(name string) *ElementBuilder {
+	builder.node.AddBooleanAttribute(name)
+	return builder
+}
+
+//
+// Add a text node with the given text as the last child of the
+// element.
+//
+func (builder *ElementBuilder) Text(text string) *ElementBuilder {
+	return builder.Children(NewText(text))
+}
+
+//
+// Write the element as a self-closing tag, such as `<x />`, when
+// it has no children.
+//
+func (builder *ElementBuilder) SelfClosing() *ElementBuilder {
+	builder.node.IsSelfClosing = true
+	return builder
+}
+
+//
+// Add the nodes built by the given builders as the last children
+// of the element. Nodes that are attached elsewhere are moved. The
+// `nil` builders, and the builders that build no node, are ignored.
+//
+func (builder *ElementBuilder) Children(children ...NodeBuilder) *ElementBuilder {
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+
+		node := child.Build()
+		if node != nil {
+			builder.node.InsertChildAt(builder.node.NumChildren(), node)
+		}
+	}
+
+	return builder
+}
+
+//
+// Return the element that was built. Calling this again returns
+// the same element, including any changes made by the builder in
+// between. A `nil` builder builds nothing.
+//
+func (builder *ElementBuilder) Build() *HtmlNode {
+	if builder == nil {
+		return nil
+	}
+
+	return builder.node
+}
+
+//
+// Return this node, so that nodes can be passed wherever a
+// `NodeBuilder` is expected.
+//
+func (node *HtmlNode) Build() *HtmlNode {
+	return node
+}
@@ -0,0 +1,71 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElementBuilder(t *testing.T) {
+	node := El("div").Attr("class", "x").Attr("data-id", "1").Children(
+		El("b").Text("Hello"),
+		NewText(" World & more"),
+		El("br"),
+		El("custom:Icon").Attr("name", "star").SelfClosing(),
+		NewComment(" note "),
+	).Build()
+
+	assert.Equal(t, "<div class=\"x\" data-id=\"1\"><b>Hello</b> World &amp; more<br><custom:Icon name=\"star\" /><!-- note --></div>", node.String())
+
+	// all nodes are linked
+	assert.Nil(t, node.Parent())
+	for _, child := range node.Children() {
+		assert.Same(t, node, child.Parent())
+	}
+	assert.Same(t, node.First(), node.First().First().Parent())
+
+	// the built element can be queried like a parsed one
+	found, err := node.Query("custom\\:icon[name=star]")
+	assert.NoError(t, err)
+	assert.NotNil(t, found)
+}
+
+func TestElementBuilderMovesChildren(t *testing.T) {
+	doc, err := getDoc("<p><span>moved</span>kept</p>")
+	assert.NoError(t, err)
+
+	p := doc.First()
+	span := p.First()
+	node := El("div").Children(span, nil).Build()
+
+	assert.Equal(t, "<div><span>moved</span></div>", node.String())
+	assert.Equal(t, "<p>kept</p>", p.String())
+	assert.Same(t, node, span.Parent())
+
+	// an element cannot become its own child
+	builder := El("a")
+	builder.Children(builder)
+	assert.False(t, builder.Build().HasChildren())
+	assert.Same(t, builder.Build(), builder.Build())
+}
+
+func TestElementBuilderNilChildren(t *testing.T) {
+	var builder *ElementBuilder
+	var node *HtmlNode
+	assert.Nil(t, builder.Build())
+
+	// typed nils are ignored like untyped ones
+	div := El("div").Children(builder, node, nil, El("b")).Build()
+	assert.Equal(t, "<div><b></b></div>", div.String())
+}
@@ -0,0 +1,143 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"strings"
+)
+
+//
+// A live view over the classes of a node. The classes are read
+// from all `class` attributes of the node, in order. Any change
+// writes all classes back into the first `class` attribute, and
+// removes the other `class` attributes.
+//
+type ClassList struct {
+	node *HtmlNode // the node whose classes are viewed
+}
+
+//
+// Return the list of classes of this node.
+//
+func (node *HtmlNode) ClassList() *ClassList {
+	return &ClassList{
+		node: node,
+	}
+}
+
+//
+// Return all classes of the node, in order, without duplicates.
+//
+func (list *ClassList) Values() []string {
+	values := make([]string, 0)
+	for _, attr := range list.node.GetAttributes("class") {
+		for _, name := range strings.Fields(attr.Value) {
+			if !containsString(values, name) {
+				values = append(values, name)
+			}
+		}
+	}
+
+	return values
+}
+
+//
+// Return the number of distinct classes of the node.
+//
+func (list *ClassList) Length() int {
+	return len(list.Values())
+}
+
+//
+// Check if the node has the given class. Class names are matched
+// case-sensitively.
+//
+func (list *ClassList) Contains(name string) bool {
+	return containsString(list.Values(), name)
+}
+
+//
+// Add the given classes to the node, unless they already exist.
+//
+func (list *ClassList) Add(names ...string) {
+	values := list.Values()
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name != "" && !containsString(values, name) {
+			values = append(values, name)
+		}
+	}
+
+	list.update(values)
+}
+
+//
+// Remove the given classes from the node.
+//
+func (list *ClassList) Remove(names ...string) {
+	values := make([]string, 0)
+	for _, value := range list.Values() {
+		if !containsString(names, value) {
+			values = append(values, value)
+		}
+	}
+
+	list.update(values)
+}
+
+//
+// Remove the given class if the node has it, add it otherwise.
+//
+// Returns `true` if the node has the class afterwards, `false`
+// otherwise.
+//
+func (list *ClassList) Toggle(name string) bool {
+	if list.Contains(name) {
+		list.Remove(name)
+		return false
+	}
+
+	list.Add(name)
+	return list.Contains(name)
+}
+
+//
+// Return the classes as they would be written in the `class`
+// attribute.
+//
+func (list *ClassList) String() string {
+	return strings.Join(list.Values(), " ")
+}
+
+//
+// Write the given classes back to the node.
+//
+func (list *ClassList) update(values []string) {
+	if len(values) == 0 && !list.node.HasAttribute("class") {
+		return
+	}
+
+	list.node.mergeAttributes("class", strings.Join(values, " "))
+}
+
+//
+// Check if the given list contains the given value.
+//
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,72 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassList(t *testing.T) {
+	div := parseWithAttributeOptions(t, "<div id='x' CLASS=' a  b ' class='c a'></div>", false, true, FirstAttributeWins)
+	classes := div.ClassList()
+	assert.Equal(t, []string{"a", "b", "c"}, classes.Values())
+	assert.Equal(t, 3, classes.Length())
+	assert.True(t, classes.Contains("c"))
+	assert.False(t, classes.Contains("A"))
+
+	// reading does not change the attributes
+	assert.Equal(t, 3, div.NumAttributes())
+
+	// changes merge duplicate attributes into the first one
+	classes.Add("d", "a", " ")
+	assert.Equal(t, "<div id=\"x\" CLASS=\"a b c d\"></div>", div.String())
+
+	classes.Remove("b", "x")
+	assert.Equal(t, "a c d", classes.String())
+
+	assert.False(t, classes.Toggle("a"))
+	assert.True(t, classes.Toggle("e"))
+	assert.Equal(t, "c d e", div.GetAttribute("class").Value)
+
+	classes.Remove("c", "d", "e")
+	assert.Equal(t, 0, classes.Length())
+	assert.Equal(t, "<div id=\"x\" CLASS=\"\"></div>", div.String())
+}
+
+func TestClassListWithoutAttribute(t *testing.T) {
+	node := NewElement("p")
+	classes := node.ClassList()
+	assert.Equal(t, []string{}, classes.Values())
+
+	// removing from nothing does not add an attribute
+	classes.Remove("a")
+	assert.False(t, node.HasAttribute("class"))
+
+	classes.Add("a")
+	assert.Equal(t, "<p class=\"a\"></p>", node.String())
+}
+
+func TestClassListOfBracedValue(t *testing.T) {
+	options := getDefaultOptions()
+	options.JsxAttributes = true
+	doc, err := ParseWithOptions(strings.NewReader("<div class={cls}></div>"), options)
+	assert.NoError(t, err)
+
+	// the updated value is no longer code
+	div := doc.First()
+	div.ClassList().Add("x")
+	assert.Equal(t, QuotedValue, div.GetAttribute("class").ValueStyle)
+	assert.Equal(t, "<div class=\"cls x\"></div>", div.String())
+}
@@ -0,0 +1,126 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+//
+// Enum to define how severe a reported diagnostic is.
+//
+type Severity uint32
+
+// Enumeration
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityError:
+		return "error"
+
+	case SeverityWarning:
+		return "warning"
+
+	case SeverityInfo:
+		return "info"
+	}
+
+	return "unknown"
+}
+
+//
+// Machine-readable code identifying the kind of problem
+// that was reported.
+//
+type DiagnosticCode string
+
+// Enumeration
+const (
+	// an end tag that did not match any open element was ignored
+	StrayEndTag DiagnosticCode = "stray-end-tag"
+
+	// an element was closed implicitly by the end tag of an ancestor
+	ImplicitlyClosedElement DiagnosticCode = "implicitly-closed-element"
+
+	// an element was still open when the input ended
+	UnclosedElement DiagnosticCode = "unclosed-element"
+
+	// an attribute was specified more than once on the same tag
+	DuplicateAttribute DiagnosticCode = "duplicate-attribute"
+
+	// a comment was not terminated, or was not a real `<!-- -->` comment
+	MalformedComment DiagnosticCode = "malformed-comment"
+
+	// a brace within a tag was never closed
+	UnterminatedExpression DiagnosticCode = "unterminated-expression"
+)
+
+//
+// A single problem that was encountered, and recovered from,
+// when parsing the markup.
+//
+type Diagnostic struct {
+	Code     DiagnosticCode // the machine-readable code
+	Severity Severity       // how severe the problem is
+	Message  string         // human-readable description
+	Position Position       // where in the source the problem was detected
+}
+
+//
+// Return the diagnostic as `line:column: severity: message [code]`.
+//
+func (diagnostic *Diagnostic) String() string {
+	return diagnostic.Position.String() + ": " + diagnostic.Severity.String() + ": " + diagnostic.Message + " [" + string(diagnostic.Code) + "]"
+}
+
+//
+// A list of diagnostics in the order they were encountered.
+//
+type Diagnostics []*Diagnostic
+
+//
+// Check if any of the diagnostics is of the given severity.
+//
+func (diagnostics Diagnostics) HasSeverity(severity Severity) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == severity {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// Return all diagnostics that have the given code. This method
+// never returns a `nil`.
+//
+func (diagnostics Diagnostics) WithCode(code DiagnosticCode) Diagnostics {
+	result := make(Diagnostics, 0)
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Code == code {
+			result = append(result, diagnostic)
+		}
+	}
+
+	return result
+}
+
+//
+// The result of parsing markup: the parsed elements along with
+// every problem that the lenient parser recovered from.
+//
+type ParseResult struct {
+	Elements    *HtmlElements // the parsed elements
+	Diagnostics Diagnostics   // the problems encountered, if any
+}
@@ -0,0 +1,96 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getDiagnostics(t *testing.T, html string) Diagnostics {
+	result, err := ParseWithDiagnostics(strings.NewReader(html), nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, result.Elements)
+	return result.Diagnostics
+}
+
+func TestNoDiagnostics(t *testing.T) {
+	diagnostics := getDiagnostics(t, "<html><head /><body>Hello <!-- c --></body></html>")
+	assert.Equal(t, 0, len(diagnostics))
+}
+
+func TestStrayEndTagDiagnostic(t *testing.T) {
+	diagnostics := getDiagnostics(t, "<div>\n  </span></div>")
+	assert.Equal(t, 1, len(diagnostics))
+
+	diagnostic := diagnostics[0]
+	assert.Equal(t, StrayEndTag, diagnostic.Code)
+	assert.Equal(t, SeverityWarning, diagnostic.Severity)
+	assert.Equal(t, Position{Offset: 8, Line: 2, Column: 3}, diagnostic.Position)
+	assert.Equal(t, "2:3: warning: Ignored end tag 'span' as there is no matching open element [stray-end-tag]", diagnostic.String())
+}
+
+func TestImplicitlyClosedDiagnostic(t *testing.T) {
+	diagnostics := getDiagnostics(t, "<div><span><b></div>")
+	assert.Equal(t, 2, len(diagnostics.WithCode(ImplicitlyClosedElement)))
+	assert.Equal(t, 14, diagnostics[0].Position.Offset)
+}
+
+func TestUnclosedElementDiagnostic(t *testing.T) {
+	diagnostics := getDiagnostics(t, "<div><p>Hello")
+	assert.Equal(t, 2, len(diagnostics))
+	assert.Equal(t, UnclosedElement, diagnostics[0].Code)
+	assert.Equal(t, 13, diagnostics[0].Position.Offset)
+	assert.True(t, strings.Contains(diagnostics[0].Message, "'p'"))
+	assert.True(t, strings.Contains(diagnostics[1].Message, "'div'"))
+}
+
+func TestDuplicateAttributeDiagnostic(t *testing.T) {
+	diagnostics := getDiagnostics(t, "<html class='a1' class='b1'></html>")
+	assert.Equal(t, 1, len(diagnostics))
+	assert.Equal(t, DuplicateAttribute, diagnostics[0].Code)
+}
+
+func TestMalformedCommentDiagnostic(t *testing.T) {
+	diagnostics := getDiagnostics(t, "<!-- fine --><!bogus><?xml version='1.0'?><!-- unterminated")
+	assert.Equal(t, 3, len(diagnostics.WithCode(MalformedComment)))
+	assert.False(t, diagnostics.HasSeverity(SeverityError))
+	assert.True(t, diagnostics.HasSeverity(SeverityWarning))
+}
+
+type failingReader struct{}
+
+func (reader failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestReaderFailure(t *testing.T) {
+	result, err := ParseWithDiagnostics(failingReader{}, nil)
+	assert.Error(t, err)
+	assert.Nil(t, result)
+
+	result, err = ParseWithDiagnostics(nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
+
+func TestDiagnosticsUseRawNames(t *testing.T) {
+	diagnostics := getDiagnostics(t, "<custom:PageBody><b></custom:PAGEBODY>")
+	assert.Equal(t, 1, len(diagnostics))
+	assert.Equal(t, "Element 'b' was implicitly closed by end tag 'custom:PAGEBODY'", diagnostics[0].Message)
+
+	diagnostics = getDiagnostics(t, "<custom:PageBody>")
+	assert.Equal(t, "Element 'custom:PageBody' was not closed before end of input", diagnostics[0].Message)
+}
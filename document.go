@@ -70,7 +70,7 @@ func (document *HtmlDocument) GetDocType() *HtmlNode {
 		return nil
 	}
 
-	for _, node := range document.nodes {
+	for _, node := range document.list().nodes {
 		if node.NodeType == DoctypeNode {
 			return node
 		}
@@ -74,6 +74,13 @@ func TestDoctype(t *testing.T) {
 
 	assert.Equal(t, 2, doc.Length())
 	assert.Equal(t, DoctypeNode, doc.First().NodeType)
+
+	// the doctype is kept where it appears
+	doc, err = getDoc("<!-- generated --><!doctype html><html />")
+	assert.NoError(t, err)
+	assert.Equal(t, CommentNode, doc.First().NodeType)
+	assert.Equal(t, DoctypeNode, doc.Get(1).NodeType)
+	assert.NotNil(t, doc.AsHtmlDocument().GetDocType())
 }
 
 func TestReplaceHead(t *testing.T) {
@@ -88,3 +95,14 @@ func TestReplaceHead(t *testing.T) {
 	assert.NotNil(t, doc.AsHtmlDocument().Head())
 	assert.NotEqual(t, node, doc.AsHtmlDocument().Head())
 }
+
+func TestZeroValueDocument(t *testing.T) {
+	document := HtmlDocument{}
+	assert.True(t, document.IsEmpty())
+	assert.Nil(t, document.Head())
+	assert.Nil(t, document.GetDocType())
+
+	document.InsertLast(NewElement("html"))
+	assert.Equal(t, 1, document.Length())
+	assert.NoError(t, document.checkInvariants())
+}
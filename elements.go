@@ -24,7 +24,17 @@ import (
 // provide are different than the standard ones.
 //
 type HtmlElements struct {
-	nodes []*HtmlNode // list of nodes at the top level
+	_list *elementList // the nodes, shared by all copies of this instance
+}
+
+//
+// The nodes of an `HtmlElements`. These are kept apart so that
+// copies of an `HtmlElements`, such as the one within the
+// `HtmlDocument` returned by `AsHtmlDocument()`, share them.
+//
+type elementList struct {
+	nodes  []*HtmlNode // list of nodes at the top level
+	trivia string      // raw markup dropped by the parser before the first node
 }
 
 //
@@ -34,7 +44,9 @@ type HtmlElements struct {
 //
 func NewHtmlElements() *HtmlElements {
 	return &HtmlElements{
-		nodes: make([]*HtmlNode, 0),
+		_list: &elementList{
+			nodes: make([]*HtmlNode, 0),
+		},
 	}
 }
 
@@ -43,10 +55,14 @@ func NewHtmlElements() *HtmlElements {
 // instance. Note, in case of fragments, most of the `HtmlDocument`
 // functions will return `nil` unless you add them (for example, for
 // a simple fragment, `document.Head()` will return `nil`).
+// The document shares the nodes with this instance, so changes
+// made via either of them are visible in both.
 //
 func (elements *HtmlElements) AsHtmlDocument() *HtmlDocument {
 	return &HtmlDocument{
-		HtmlElements: *elements,
+		HtmlElements: HtmlElements{
+			_list: elements.list(),
+		},
 	}
 }
 
@@ -56,11 +72,11 @@ func (elements *HtmlElements) AsHtmlDocument() *HtmlDocument {
 // Return the length of elements inside this instance.
 //
 func (elements *HtmlElements) Length() int {
-	if elements.nodes == nil {
+	if elements.list().nodes == nil {
 		return 0
 	}
 
-	return len(elements.nodes)
+	return len(elements.list().nodes)
 }
 
 //
@@ -68,7 +84,7 @@ func (elements *HtmlElements) Length() int {
 // empty if it has no child node.
 //
 func (elements *HtmlElements) IsEmpty() bool {
-	if len(elements.nodes) == 0 {
+	if len(elements.list().nodes) == 0 {
 		return true
 	}
 
@@ -95,7 +111,7 @@ func (elements *HtmlElements) Last() *HtmlNode {
 // Return all child nodes of this element.
 //
 func (elements *HtmlElements) Nodes() []*HtmlNode {
-	return elements.nodes
+	return elements.list().nodes
 }
 
 //
@@ -108,7 +124,7 @@ func (elements *HtmlElements) Get(index int) *HtmlNode {
 		return nil
 	}
 
-	return elements.nodes[index]
+	return elements.list().nodes[index]
 }
 
 //----- FIND methods
@@ -127,7 +143,7 @@ func (elements *HtmlElements) GetBefore(child *HtmlNode) *HtmlNode {
 		return nil
 	}
 
-	for index, node := range elements.nodes {
+	for index, node := range elements.list().nodes {
 		if node == child {
 			return elements.Get(index - 1)
 		}
@@ -150,7 +166,7 @@ func (elements *HtmlElements) GetAfter(child *HtmlNode) *HtmlNode {
 		return nil
 	}
 
-	for index, node := range elements.nodes {
+	for index, node := range elements.list().nodes {
 		if node == child {
 			return elements.Get(index + 1)
 		}
@@ -172,9 +188,9 @@ func (elements *HtmlElements) GetChildrenByName(name string) *HtmlElements {
 	}
 
 	result := NewHtmlElements()
-	for _, child := range elements.nodes {
+	for _, child := range elements.list().nodes {
 		if strings.EqualFold(child.NodeName(), name) {
-			result.nodes = append(result.nodes, child)
+			result.list().nodes = append(result.list().nodes, child)
 		}
 	}
 
@@ -196,7 +212,7 @@ func (elements *HtmlElements) GetElementsByName(name string) *HtmlElements {
 	}
 
 	result := NewHtmlElements()
-	for _, child := range elements.nodes {
+	for _, child := range elements.list().nodes {
 		child.getElementsByNameInternal(name, result)
 	}
 
@@ -218,7 +234,7 @@ func (elements *HtmlElements) GetElementById(id string) *HtmlNode {
 		return nil
 	}
 
-	for _, child := range elements.nodes {
+	for _, child := range elements.list().nodes {
 		found := child.GetElementById(id)
 		if found != nil {
 			return found
@@ -234,78 +250,65 @@ func (elements *HtmlElements) GetElementById(id string) *HtmlNode {
 // Insert a node at given index.
 // If index is less than or equal to zero, the node is inserted as first element.
 // If index is equal or greater than length, the node is inserted as last element.
+// The node is detached from where it was attached before.
 //
 func (elements *HtmlElements) InsertAt(index int, newNode *HtmlNode) {
-	// attach the node
-	newNode._parent = nil
-	newNode._wrappingElements = elements
-
-	// first addition
-	if index <= 0 {
-		elements.nodes = append([]*HtmlNode{newNode}, elements.nodes...)
+	if newNode == nil {
 		return
 	}
 
-	// falls at the end
-	num := len(elements.nodes)
-	if index >= num {
-		elements.nodes = append(elements.nodes, newNode)
-		return
+	// moving a node forward within this list shifts the index
+	if newNode._parent == nil && elements.wraps(newNode) {
+		current := indexOfNode(elements.list().nodes, newNode, 0)
+		if current >= 0 && current < index {
+			index--
+		}
 	}
 
-	// falls in between
-	prefix := elements.nodes[:index]
-	suffix := elements.nodes[index:]
-	elements.nodes = append(prefix, newNode)
-	elements.nodes = append(elements.nodes, suffix...)
-	return
+	newNode.RemoveMe()
+	elements.insertAt(index, newNode)
 }
 
 //
-// Insert a newNode before another childNode.
+// Insert a newNode before another childNode. The newNode is
+// detached from where it was attached before.
 // Returns `true` if the newNode was added successfully.
 // Returns `false` if there are no elements in this instance
 // or the child instance cannot be found.
 //
 func (elements *HtmlElements) InsertBefore(childNode *HtmlNode, newNode *HtmlNode) bool {
-	if !childNode.HasChildren() {
+	if newNode == nil || indexOfNode(elements.list().nodes, childNode, 0) < 0 {
 		return false
 	}
 
-	for index, child := range elements.nodes {
-		if child == childNode {
-			newIndex := index - 1
-			if newIndex == -1 {
-				newIndex = 0
-			}
-
-			elements.InsertAt(newIndex, newNode)
-			return true
-		}
+	if childNode == newNode {
+		return true
 	}
 
-	return false
+	newNode.RemoveMe()
+	elements.insertAt(indexOfNode(elements.list().nodes, childNode, 0), newNode)
+	return true
 }
 
 //
-// Insert a newNode after given childNode.
+// Insert a newNode after given childNode. The newNode is
+// detached from where it was attached before.
 // Returns `true` if the newNode was added successfully.
 // Returns `false` if there are no elements in this instance
 // or the child instance cannot be found.
 //
 func (elements *HtmlElements) InsertAfter(childNode *HtmlNode, newNode *HtmlNode) bool {
-	if !childNode.HasChildren() {
+	if newNode == nil || indexOfNode(elements.list().nodes, childNode, 0) < 0 {
 		return false
 	}
 
-	for index, kid := range elements.nodes {
-		if kid == childNode {
-			elements.InsertAt(index+1, newNode)
-			return true
-		}
+	if childNode == newNode {
+		return true
 	}
 
-	return false
+	newNode.RemoveMe()
+	elements.insertAt(indexOfNode(elements.list().nodes, childNode, 0)+1, newNode)
+	return true
 }
 
 //
@@ -334,13 +337,13 @@ func (elements *HtmlElements) Empty() {
 	}
 
 	// detach nodes
-	for _, node := range elements.nodes {
+	for _, node := range elements.list().nodes {
 		node._parent = nil
 		node._wrappingElements = nil
 	}
 
 	// create new slice
-	elements.nodes = make([]*HtmlNode, 0)
+	elements.list().nodes = make([]*HtmlNode, 0)
 }
 
 //
@@ -354,10 +357,10 @@ func (elements *HtmlElements) Remove(childNode *HtmlNode) bool {
 		return false
 	}
 
-	for index, child := range elements.nodes {
+	for index, child := range elements.list().nodes {
 		if child == childNode {
 			childNode.detach()
-			elements.nodes = append(elements.nodes[:index], elements.nodes[index+1:]...)
+			elements.list().nodes = append(elements.list().nodes[:index], elements.list().nodes[index+1:]...)
 			return true
 		}
 	}
@@ -384,21 +387,41 @@ func (elements *HtmlElements) Replace(childNode *HtmlNode, newNode *HtmlNode) bo
 		return false
 	}
 
-	for index, child := range elements.nodes {
-		if child == childNode {
-			elements.nodes[index] = newNode
+	if indexOfNode(elements.list().nodes, childNode, 0) < 0 {
+		return false
+	}
 
-			// detach & attach
-			childNode.detach()
-			newNode._parent = nil
-			newNode._wrappingElements = elements
+	if childNode == newNode {
+		return true
+	}
 
-			// all done
-			return true
-		}
+	// detach & attach
+	newNode.RemoveMe()
+	index := indexOfNode(elements.list().nodes, childNode, 0)
+	childNode.detach()
+	elements.list().nodes[index] = newNode
+	elements.adopt(newNode)
+
+	// all done
+	return true
+}
+
+//----- Copy methods
+
+//
+// Return a deep copy of this list of elements. All nodes, along with
+// their attributes and children, are copied so that the copy can be
+// modified without affecting this list.
+//
+func (elements *HtmlElements) Clone() *HtmlElements {
+	clone := NewHtmlElements()
+	clone.list().trivia = elements.list().trivia
+
+	for _, node := range elements.list().nodes {
+		clone.appendNode(node.Clone(true))
 	}
 
-	return false
+	return clone
 }
 
 //----- tostring()
@@ -409,11 +432,11 @@ func (elements *HtmlElements) String() (string, error) {
 	}
 
 	builder := strings.Builder{}
-	for _, node := range elements.nodes {
-		node.WriteToBuilder(&builder)
+	err := elements.Render(&builder, RenderOptions{})
+	if err != nil {
+		return "", err
 	}
 
-	builder.WriteString("\n")
 	return builder.String(), nil
 }
 
@@ -422,8 +445,10 @@ func (elements *HtmlElements) WrappedString(node *HtmlNode) (string, error) {
 		return "", errors.New("Wrapping node cannot be nil")
 	}
 
-	node._children = elements.nodes
-	return node.String(), nil
+	// render a copy so that the nodes stay where they are
+	wrapper := node.Clone(false)
+	wrapper._children = elements.list().nodes
+	return wrapper.String(), nil
 }
 
 //----- Internal methods
@@ -454,6 +479,65 @@ func (elements *HtmlElements) addNodeToStack(node *HtmlNode, stack *nodeStack) {
 // Append the given node to the list of nodes in this document.
 //
 func (elements *HtmlElements) appendNode(node *HtmlNode) {
+	elements.collect(node)
+	elements.adopt(node)
+}
+
+//
+// Add the given node to the end of this list without attaching
+// it, such as when collecting the nodes that match a search.
+//
+func (elements *HtmlElements) collect(node *HtmlNode) {
+	list := elements.list()
+	list.nodes = append(list.nodes, node)
+}
+
+//
+// Check if the given node is a top-level node of this list, or of
+// a copy of it.
+//
+func (elements *HtmlElements) wraps(node *HtmlNode) bool {
+	return node._wrappingElements != nil && node._wrappingElements.list() == elements.list()
+}
+
+//
+// Return the nodes of this list, creating them for a zero value
+// so that all copies made after share them.
+//
+func (elements *HtmlElements) list() *elementList {
+	if elements._list == nil {
+		elements._list = &elementList{}
+	}
+
+	return elements._list
+}
+
+//
+// Insert the given detached node at the given index, clamping the
+// index to the bounds of the list.
+//
+func (elements *HtmlElements) insertAt(index int, newNode *HtmlNode) {
+	num := len(elements.list().nodes)
+	if index < 0 {
+		index = 0
+	}
+	if index > num {
+		index = num
+	}
+
+	// we copy the nodes so that we do not overwrite the
+	// suffix that shares the same backing array
+	updated := make([]*HtmlNode, 0, num+1)
+	updated = append(updated, elements.list().nodes[:index]...)
+	updated = append(updated, newNode)
+	elements.list().nodes = append(updated, elements.list().nodes[index:]...)
+	elements.adopt(newNode)
+}
+
+//
+// Set the links of the given node that was just added to this list.
+//
+func (elements *HtmlElements) adopt(node *HtmlNode) {
+	node._parent = nil
 	node._wrappingElements = elements
-	elements.nodes = append(elements.nodes, node)
 }
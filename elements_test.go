@@ -12,6 +12,7 @@
 package lhtml
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -140,3 +141,112 @@ func TestDocGetElementById(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Nil(t, doc.GetElementById("hello"))
 }
+
+func TestCloneElements(t *testing.T) {
+	html := "<!DOCTYPE html>\n<p>one</p><p>two</p>"
+	doc, err := getDoc(html)
+	assert.NoError(t, err)
+
+	clone := doc.Clone()
+	assert.Equal(t, doc.Length(), clone.Length())
+	for index, node := range clone.Nodes() {
+		assert.NotSame(t, doc.Get(index), node)
+		assert.Same(t, clone, node._wrappingElements)
+		assert.Nil(t, node.Parent())
+	}
+
+	// the source is reproduced from the copy
+	builder := strings.Builder{}
+	assert.NoError(t, clone.Render(&builder, RenderOptions{PreserveSource: true}))
+	assert.Equal(t, html, builder.String())
+
+	// modifying the copy leaves the original untouched
+	clone.Remove(clone.Last())
+	clone.First().Data = "xml"
+	s, err := doc.String()
+	assert.NoError(t, err)
+	assert.Equal(t, "<!DOCTYPE html><p>one</p><p>two</p>", s)
+}
+
+func TestDocInsertBeforeAndAfter(t *testing.T) {
+	doc, err := getDoc("<a></a><b></b>")
+	assert.NoError(t, err)
+
+	a := doc.First()
+	b := doc.Last()
+
+	// elements without children can be used
+	assert.True(t, doc.InsertBefore(b, NewText("1")))
+	assert.True(t, doc.InsertAfter(b, NewText("2")))
+	assert.True(t, doc.InsertBefore(a, NewText("0")))
+	s, _ := doc.String()
+	assert.Equal(t, "0<a></a>1<b></b>2", s)
+
+	// moving a node within the list
+	assert.True(t, doc.InsertAfter(doc.Last(), a))
+	doc.InsertAt(1, doc.Last())
+	doc.InsertAt(0, doc.Get(2))
+	s, _ = doc.String()
+	assert.Equal(t, "10<a></a><b></b>2", s)
+
+	// moving forward accounts for the node being removed first
+	doc.InsertAt(3, doc.First())
+	s, _ = doc.String()
+	assert.Equal(t, "0<a></a>1<b></b>2", s)
+	assert.NoError(t, doc.checkInvariants())
+
+	// not in the list
+	assert.False(t, doc.InsertBefore(NewText("x"), NewText("y")))
+	assert.False(t, doc.InsertAfter(a, nil))
+}
+
+func TestDocInsertMovesNode(t *testing.T) {
+	doc, err := getDoc("<div><p>1</p></div>")
+	assert.NoError(t, err)
+
+	div := doc.First()
+	p := div.First()
+
+	doc.InsertLast(p)
+	assert.False(t, div.HasChildren())
+	assert.Nil(t, p.Parent())
+	assert.Same(t, doc, p._wrappingElements)
+	assert.NoError(t, doc.checkInvariants())
+
+	assert.True(t, doc.Replace(div, p))
+	assert.Equal(t, 1, doc.Length())
+	assert.Nil(t, div._wrappingElements)
+	assert.NoError(t, doc.checkInvariants())
+
+	// a document shares the nodes
+	document := doc.AsHtmlDocument()
+	document.InsertFirst(div)
+	assert.Equal(t, 2, doc.Length())
+	assert.True(t, div.RemoveMe())
+	assert.Equal(t, 1, document.Length())
+	assert.NoError(t, doc.checkInvariants())
+
+	document.InsertLast(div)
+	document.InsertLast(p)
+	assert.Equal(t, 2, doc.Length())
+	assert.Same(t, p, doc.Last())
+	assert.NoError(t, doc.checkInvariants())
+	assert.NoError(t, document.checkInvariants())
+}
+
+func TestWrappedString(t *testing.T) {
+	doc, err := getDoc("<p>1</p><p>2</p>")
+	assert.NoError(t, err)
+
+	wrapper := El("div").Text("ignored").Build()
+	s, err := doc.WrappedString(wrapper)
+	assert.NoError(t, err)
+	assert.Equal(t, "<div><p>1</p><p>2</p></div>", s)
+
+	// neither the wrapper, nor the nodes, are modified
+	assert.Equal(t, "<div>ignored</div>", wrapper.String())
+	assert.NoError(t, doc.checkInvariants())
+
+	_, err = doc.WrappedString(nil)
+	assert.Error(t, err)
+}
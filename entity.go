@@ -0,0 +1,210 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+//
+// Return the text of this text node as it was spelled in source,
+// such as `a &amp;&amp; b`. If the text was modified after parsing,
+// or the node was created programmatically, the text is escaped
+// as it would be when written as markup. For all other node types
+// this is the same as `Data`.
+//
+func (node *HtmlNode) RawData() string {
+	if node.NodeType != TextNode || node.options().ValueMode == RawValues {
+		return node.Data
+	}
+
+	if node._rawData != "" && node.Data == node._decodedData {
+		return node._rawData
+	}
+
+	if node.inRawTextElement() {
+		return node.Data
+	}
+
+	return escapeText(node.Data)
+}
+
+//
+// Return the text of this text node with all character references,
+// such as `&amp;` or `&nbsp;`, decoded. Text within raw text elements,
+// such as `script` or `style`, is never decoded. For all other node
+// types this is the same as `Data`.
+//
+func (node *HtmlNode) DecodedData() string {
+	if node.NodeType != TextNode || node.options().ValueMode == DecodedValues {
+		return node.Data
+	}
+
+	if node._rawData != "" && node.Data == node._rawData {
+		return node._decodedData
+	}
+
+	if node.inRawTextElement() {
+		return node.Data
+	}
+
+	return html.UnescapeString(node.Data)
+}
+
+//
+// Return the value of this attribute as it was spelled in source,
+// excluding any quotes. If the value was modified after parsing,
+// or the attribute was created programmatically, the value is
+// escaped as it would be when written as markup.
+//
+func (attr *HtmlAttribute) RawValue() string {
+	if attr.options().ValueMode == RawValues {
+		return attr.Value
+	}
+
+	if attr._rawValue != "" && attr.Value == attr._decodedValue {
+		return attr._rawValue
+	}
+
+	return escapeAttributeValue(attr.Value)
+}
+
+//
+// Return the value of this attribute with all character references,
+// such as `&amp;` or `&quot;`, decoded.
+//
+func (attr *HtmlAttribute) DecodedValue() string {
+	if attr.options().ValueMode == DecodedValues {
+		return attr.Value
+	}
+
+	if attr._rawValue != "" && attr.Value == attr._rawValue {
+		return attr._decodedValue
+	}
+
+	return html.UnescapeString(attr.Value)
+}
+
+//----- Internal methods
+
+//
+// Create a text node with the given raw and decoded spellings of
+// its text, using the one selected by the options as its data.
+//
+func newTextNode(raw string, decoded string, options *ParseOptions) *HtmlNode {
+	node := &HtmlNode{
+		NodeType:     TextNode,
+		Data:         decoded,
+		_rawData:     raw,
+		_decodedData: decoded,
+		_options:     options,
+	}
+	if options.ValueMode == RawValues {
+		node.Data = raw
+	}
+
+	return node
+}
+
+//
+// Decode the character references in the content of the raw text
+// element with the given lower-cased name, if the element allows
+// them.
+//
+func decodeRawText(name string, text string) string {
+	if escapableRawTextElements[name] {
+		return html.UnescapeString(text)
+	}
+
+	return text
+}
+
+//
+// Check if this element keeps its content as a single text node
+// that is neither decoded, nor escaped.
+//
+func (node *HtmlNode) hasRawText() bool {
+	return node.NodeType == ElementNode && node.options().isRawTextElement(node._tagName) && !escapableRawTextElements[node._tagName]
+}
+
+//
+// Check if this node is a child of an element whose text is
+// neither decoded, nor escaped.
+//
+func (node *HtmlNode) inRawTextElement() bool {
+	return node._parent != nil && node._parent.hasRawText()
+}
+
+//
+// Return the text of this text node as it is written as markup.
+// Raw text is written as is, as it is already escaped.
+//
+func (node *HtmlNode) markupData() string {
+	if node.options().ValueMode == RawValues {
+		return node.Data
+	}
+
+	return escapeText(node.Data)
+}
+
+//
+// Return the value of this attribute as it is written within the
+// given quote as markup, or without quotes if the quote is zero.
+// Raw values are written as is, except for the quote character.
+// Template expressions are always written as is.
+//
+func (attr *HtmlAttribute) markupValue(quote byte) string {
+	if !attr.HasExpressions() {
+		return attr.markupText(attr.Value, quote)
+	}
+
+	builder := strings.Builder{}
+	for _, part := range attr.ValueParts() {
+		if part.IsExpression {
+			builder.WriteString(part.Delimiters.Open + part.Data + part.Delimiters.Close)
+		} else {
+			builder.WriteString(attr.markupText(part.Data, quote))
+		}
+	}
+
+	return builder.String()
+}
+
+//
+// Return the given text of the value of this attribute as it is
+// written within the given quote as markup.
+//
+func (attr *HtmlAttribute) markupText(text string, quote byte) string {
+	if attr.options().ValueMode == RawValues {
+		switch quote {
+		case '"':
+			return strings.ReplaceAll(text, "\"", "&quot;")
+
+		case '\'':
+			return strings.ReplaceAll(text, "'", "&#39;")
+		}
+
+		return text
+	}
+
+	switch quote {
+	case '"':
+		return escapeAttributeValue(text)
+
+	case '\'':
+		return singleQuotedValueEscaper.Replace(text)
+	}
+
+	return strings.ReplaceAll(text, "&", "&amp;")
+}
@@ -0,0 +1,93 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const entityHtml = `<p title='say "hi" &amp; bye' data-x=a&lt;b>{{ a &amp;&amp; b }}&nbsp;&copy</p><script>a &amp;&amp; b</script>`
+
+func TestDecodedValues(t *testing.T) {
+	doc, err := getDoc(entityHtml)
+	assert.NoError(t, err)
+
+	p := doc.First()
+	text := p.First()
+	assert.Equal(t, "{{ a && b }} ©", text.Data)
+	assert.Equal(t, text.Data, text.DecodedData())
+	assert.Equal(t, "{{ a &amp;&amp; b }}&nbsp;&copy", text.RawData())
+
+	title := p.GetAttribute("title")
+	assert.Equal(t, `say "hi" & bye`, title.Value)
+	assert.Equal(t, title.Value, title.DecodedValue())
+	assert.Equal(t, `say "hi" &amp; bye`, title.RawValue())
+	assert.Equal(t, "a&lt;b", p.GetAttribute("data-x").RawValue())
+
+	// raw text is never decoded
+	script := doc.Last().First()
+	assert.Equal(t, "a &amp;&amp; b", script.Data)
+	assert.Equal(t, script.Data, script.RawData())
+
+	// modified values are escaped
+	text.Data = "a < b"
+	assert.Equal(t, "a &lt; b", text.RawData())
+	title.Value = "x & y"
+	assert.Equal(t, "x &amp; y", title.RawValue())
+	script.Data = "a && b"
+	assert.Equal(t, "a && b", script.RawData())
+}
+
+func TestRawValues(t *testing.T) {
+	options := getDefaultOptions()
+	options.ValueMode = RawValues
+	doc, err := ParseWithOptions(strings.NewReader(entityHtml), options)
+	assert.NoError(t, err)
+
+	p := doc.First()
+	text := p.First()
+	assert.Equal(t, "{{ a &amp;&amp; b }}&nbsp;&copy", text.Data)
+	assert.Equal(t, text.Data, text.RawData())
+	assert.Equal(t, "{{ a && b }} ©", text.DecodedData())
+
+	title := p.GetAttribute("title")
+	assert.Equal(t, `say "hi" &amp; bye`, title.Value)
+	assert.Equal(t, `say "hi" & bye`, title.DecodedValue())
+
+	// raw values are written as they are
+	s, err := doc.String()
+	assert.NoError(t, err)
+	assert.Equal(t, `<p title="say &quot;hi&quot; &amp; bye" data-x="a&lt;b">{{ a &amp;&amp; b }}&nbsp;&copy</p><script>a &amp;&amp; b</script>`, s)
+
+	// modified values are decoded
+	text.Data = "&lt;b&gt;"
+	assert.Equal(t, "<b>", text.DecodedData())
+
+	// new attributes follow the mode of the node
+	p.SetAttribute("alt", "&quot;")
+	assert.Equal(t, "\"", p.GetAttribute("alt").DecodedValue())
+}
+
+func TestProgrammaticValues(t *testing.T) {
+	node := El("a").Attr("href", "?a=1&b=2").Text("1 < 2").Build()
+	assert.Equal(t, "?a=1&amp;b=2", node.GetAttribute("href").RawValue())
+	assert.Equal(t, "?a=1&b=2", node.GetAttribute("href").DecodedValue())
+	assert.Equal(t, "1 &lt; 2", node.First().RawData())
+	assert.Equal(t, "1 < 2", node.First().DecodedData())
+
+	comment := NewComment(" &amp; ")
+	assert.Equal(t, " &amp; ", comment.RawData())
+	assert.Equal(t, " &amp; ", comment.DecodedData())
+}
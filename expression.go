@@ -0,0 +1,482 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+)
+
+//
+// The pair of delimiters that start and end a template expression,
+// such as `{{` and `}}`.
+//
+type ExpressionDelimiters struct {
+	Open  string // starts an expression
+	Close string // ends an expression
+}
+
+//
+// The delimiters used by the most common template engines. Add
+// these to `ParseOptions` to parse templates containing expressions
+// such as `{{ user.name }}`, `{% if x %}` or `${ value }`.
+//
+var CommonExpressionDelimiters = []ExpressionDelimiters{
+	{Open: "{{", Close: "}}"},
+	{Open: "{%", Close: "%}"},
+	{Open: "${", Close: "}"},
+}
+
+//
+// A part of an attribute value, which is either plain text or a
+// template expression.
+//
+type ValuePart struct {
+	Data         string               // the text, or the code of the expression without its delimiters
+	IsExpression bool                 // whether this part is an expression
+	Delimiters   ExpressionDelimiters // the delimiters of the expression
+}
+
+//
+// Create a new expression node with the given delimiters and the
+// code between them, such as `user.name`.
+//
+func NewExpression(delimiters ExpressionDelimiters, code string) *HtmlNode {
+	return &HtmlNode{
+		NodeType:    ExpressionNode,
+		Data:        code,
+		_delimiters: delimiters,
+	}
+}
+
+//
+// Return the delimiters of this expression node.
+//
+func (node *HtmlNode) Delimiters() ExpressionDelimiters {
+	return node._delimiters
+}
+
+//
+// Return the expression with its delimiters, such as `{{ x }}`,
+// for expression nodes. For all other node types this is the same
+// as `Data`.
+//
+func (node *HtmlNode) ExpressionSource() string {
+	if node.NodeType != ExpressionNode {
+		return node.Data
+	}
+
+	return node._delimiters.Open + node.Data + node._delimiters.Close
+}
+
+//
+// Split the value of this attribute into text and the template
+// expressions found using the delimiters of the options the node
+// was parsed with. A value without expressions is returned as a
+// single text part, and an empty value has no parts.
+//
+func (attr *HtmlAttribute) ValueParts() []ValuePart {
+	parts := make([]ValuePart, 0)
+	start := 0
+	for _, expression := range scanExpressions(attr.Value, attr.options().ExpressionDelimiters) {
+		if expression.offset > start {
+			parts = append(parts, ValuePart{
+				Data: attr.Value[start:expression.offset],
+			})
+		}
+
+		parts = append(parts, ValuePart{
+			Data:         expression.code(),
+			IsExpression: true,
+			Delimiters:   expression.delimiters,
+		})
+		start = expression.offset + len(expression.source)
+	}
+
+	if start < len(attr.Value) {
+		parts = append(parts, ValuePart{
+			Data: attr.Value[start:],
+		})
+	}
+
+	return parts
+}
+
+//
+// Check if the value of this attribute contains any template
+// expressions.
+//
+func (attr *HtmlAttribute) HasExpressions() bool {
+	return len(scanExpressions(attr.Value, attr.options().ExpressionDelimiters)) > 0
+}
+
+//----- Internal methods
+
+//
+// The byte that replaces every byte of an expression before the
+// markup is read by the tokenizer.
+//
+const expressionMask = '\x01'
+
+//
+// An expression found in the source.
+//
+type expression struct {
+	offset     int                  // byte offset where the expression starts
+	source     string               // the expression along with its delimiters
+	delimiters ExpressionDelimiters // the delimiters of the expression
+}
+
+//
+// Return the code of this expression without the delimiters.
+//
+func (expression *expression) code() string {
+	return expression.source[len(expression.delimiters.Open) : len(expression.source)-len(expression.delimiters.Close)]
+}
+
+//
+// A reader that replaces every byte of the template expressions
+// read from the underlying reader with `expressionMask`, so that
+// the tokenizer can never misread them as markup. As the masked
+// expressions have the same length as in source, they are restored
+// using the offsets of the tokens read.
+//
+type expressionReader struct {
+	source      io.Reader              // the underlying reader
+	reader      *bufio.Reader          // reads from the underlying reader
+	delimiters  []ExpressionDelimiters // the delimiters, longest first
+	starts      string                 // the first bytes of all open delimiters
+	offset      int                    // the number of bytes masked so far
+	pending     []byte                 // masked bytes that are yet to be read
+	expressions []*expression          // the expressions masked so far, in order
+}
+
+//
+// Create a reader that masks the expressions with the given
+// delimiters.
+//
+func newExpressionReader(reader io.Reader, delimiters []ExpressionDelimiters) *expressionReader {
+	sorted := make([]ExpressionDelimiters, 0, len(delimiters))
+	starts := ""
+	for _, pair := range delimiters {
+		if pair.Open == "" || pair.Close == "" {
+			continue
+		}
+
+		sorted = append(sorted, pair)
+		starts += pair.Open[:1]
+	}
+
+	// `{{` must be tried before `{`
+	sort.SliceStable(sorted, func(i int, j int) bool {
+		return len(sorted[i].Open) > len(sorted[j].Open)
+	})
+
+	return &expressionReader{
+		source:      reader,
+		reader:      bufio.NewReader(reader),
+		delimiters:  sorted,
+		starts:      starts,
+		expressions: make([]*expression, 0),
+	}
+}
+
+//
+// Find all expressions with the given delimiters in the given text.
+//
+func scanExpressions(text string, delimiters []ExpressionDelimiters) []*expression {
+	if len(delimiters) == 0 || text == "" {
+		return nil
+	}
+
+	reader := newExpressionReader(strings.NewReader(text), delimiters)
+	io.Copy(io.Discard, reader)
+	return reader.expressions
+}
+
+//
+// Read the masked markup.
+//
+func (reader *expressionReader) Read(buffer []byte) (int, error) {
+	for len(reader.pending) == 0 {
+		err := reader.fill()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	count := copy(buffer, reader.pending)
+	reader.pending = reader.pending[count:]
+	return count, nil
+}
+
+//
+// Read the next expression, or the text up to where the next
+// expression may start, from the underlying reader.
+//
+func (reader *expressionReader) fill() error {
+	for _, pair := range reader.delimiters {
+		peeked, _ := reader.reader.Peek(len(pair.Open))
+		if string(peeked) == pair.Open {
+			return reader.readExpression(pair)
+		}
+	}
+
+	c, err := reader.reader.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	reader.push(c)
+	for len(reader.pending) < 4096 {
+		peeked, err := reader.reader.Peek(1)
+		if err != nil || strings.IndexByte(reader.starts, peeked[0]) >= 0 {
+			return nil
+		}
+
+		reader.reader.Discard(1)
+		reader.push(peeked[0])
+	}
+
+	return nil
+}
+
+//
+// Read the expression with the given delimiters, that starts at
+// the current position, and mask it. The close delimiter is not
+// looked for within quoted strings, unless the quote is never
+// closed before the input ends or the next expression starts, in
+// which case the expression ends at the first close delimiter. If
+// the expression is never closed, it is kept as is.
+//
+func (reader *expressionReader) readExpression(pair ExpressionDelimiters) error {
+	source := make([]byte, 0, 64)
+	source = append(source, pair.Open...)
+	reader.reader.Discard(len(pair.Open))
+
+	var quote byte
+	closed := -1 // length of the source up to the first close delimiter within quotes
+	for {
+		peeked, _ := reader.reader.Peek(len(pair.Close))
+		if string(peeked) == pair.Close {
+			if quote == 0 {
+				reader.reader.Discard(len(pair.Close))
+				source = append(source, pair.Close...)
+				break
+			}
+
+			if closed < 0 {
+				closed = len(source) + len(pair.Close)
+			}
+		}
+
+		// the quote ran into the next expression
+		if quote != 0 && closed >= 0 && reader.startsExpression() {
+			source = reader.unread(source, closed)
+			break
+		}
+
+		c, err := reader.reader.ReadByte()
+		if err == io.EOF {
+			if closed >= 0 {
+				source = reader.unread(source, closed)
+				break
+			}
+
+			reader.push(source...)
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		source = append(source, c)
+		switch {
+		case quote != 0 && c == '\\':
+			escaped, err := reader.reader.ReadByte()
+			if err == nil {
+				source = append(source, escaped)
+			}
+
+		case c == quote:
+			quote = 0
+
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		}
+	}
+
+	reader.expressions = append(reader.expressions, &expression{
+		offset:     reader.offset,
+		source:     string(source),
+		delimiters: pair,
+	})
+
+	for range source {
+		reader.push(expressionMask)
+	}
+
+	return nil
+}
+
+//
+// Check if an expression starts at the current position.
+//
+func (reader *expressionReader) startsExpression() bool {
+	for _, pair := range reader.delimiters {
+		peeked, _ := reader.reader.Peek(len(pair.Open))
+		if string(peeked) == pair.Open {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// Keep the given number of bytes of the given source, and read the
+// rest of it again. Returns the bytes kept.
+//
+func (reader *expressionReader) unread(source []byte, keep int) []byte {
+	rest, _ := reader.reader.Peek(reader.reader.Buffered())
+	unread := make([]byte, 0, len(source)-keep+len(rest))
+	unread = append(unread, source[keep:]...)
+	unread = append(unread, rest...)
+
+	// what has been read ahead is carried over, so that the
+	// underlying reader is never wrapped more than once
+	reader.reader = bufio.NewReader(io.MultiReader(bytes.NewReader(unread), reader.source))
+	return source[:keep]
+}
+
+//
+// Drop the expressions that end before the given offset, once the
+// markup before it has been read and restored.
+//
+func (reader *expressionReader) discard(offset int) {
+	count := 0
+	for count < len(reader.expressions) && reader.expressions[count].offset+len(reader.expressions[count].source) <= offset {
+		count++
+	}
+
+	if count > 0 {
+		reader.expressions = append(reader.expressions[:0], reader.expressions[count:]...)
+	}
+}
+
+//
+// Add the given bytes to the masked markup.
+//
+func (reader *expressionReader) push(bytes ...byte) {
+	reader.pending = append(reader.pending, bytes...)
+	reader.offset += len(bytes)
+}
+
+//
+// Return the expressions that start within the given range of
+// offsets.
+//
+func (reader *expressionReader) within(start int, end int) []*expression {
+	expressions := reader.expressions
+	first := sort.Search(len(expressions), func(index int) bool {
+		return expressions[index].offset >= start
+	})
+
+	last := first
+	for last < len(expressions) && expressions[last].offset < end {
+		last++
+	}
+
+	return expressions[first:last]
+}
+
+//
+// Restore the expressions in the given masked markup that starts
+// at the given offset.
+//
+func (reader *expressionReader) restore(masked string, offset int) string {
+	expressions := reader.within(offset, offset+len(masked))
+	if len(expressions) == 0 {
+		return masked
+	}
+
+	builder := strings.Builder{}
+	start := 0
+	for _, expression := range expressions {
+		index := expression.offset - offset
+		end := index + len(expression.source)
+		if end > len(masked) {
+			break
+		}
+
+		builder.WriteString(masked[start:index])
+		builder.WriteString(expression.source)
+		start = end
+	}
+
+	builder.WriteString(masked[start:])
+	return builder.String()
+}
+
+//
+// Split the given text, that was read from masked markup and may
+// have been decoded since, at the masks of the given expressions.
+// Returns one more part than there are expressions.
+//
+func splitMasked(text string, expressions []*expression) []string {
+	parts := make([]string, 0, len(expressions)+1)
+	start := 0
+	for _, expression := range expressions {
+		index := strings.IndexByte(text[start:], expressionMask)
+		if index < 0 {
+			break
+		}
+
+		index += start
+		parts = append(parts, text[start:index])
+		start = index + len(expression.source)
+		if start > len(text) {
+			start = len(text)
+		}
+	}
+
+	parts = append(parts, text[start:])
+	for len(parts) < len(expressions)+1 {
+		parts = append(parts, "")
+	}
+
+	return parts
+}
+
+//
+// Restore the given expressions in the given text, that was read
+// from masked markup and may have been decoded since.
+//
+func restoreMasked(text string, expressions []*expression) string {
+	if len(expressions) == 0 {
+		return text
+	}
+
+	parts := splitMasked(text, expressions)
+	builder := strings.Builder{}
+	for index, expression := range expressions {
+		builder.WriteString(parts[index])
+		builder.WriteString(expression.source)
+	}
+
+	builder.WriteString(parts[len(expressions)])
+	return builder.String()
+}
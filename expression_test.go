@@ -0,0 +1,191 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Parse the given template with the common expression delimiters.
+//
+func getTemplate(t *testing.T, html string) *HtmlElements {
+	options := getDefaultOptions()
+	options.ExpressionDelimiters = CommonExpressionDelimiters
+
+	doc, err := ParseWithOptions(strings.NewReader(html), options)
+	assert.NoError(t, err)
+	return doc
+}
+
+func TestExpressionsInText(t *testing.T) {
+	html := "<p>Hello {{ user.name }}!{% if a<b %}<b>x</b>{% endif %}</p>"
+	doc := getTemplate(t, html)
+
+	p := doc.First()
+	assert.Equal(t, 6, p.NumChildren())
+	assert.Equal(t, "Hello ", p.First().Data)
+
+	expression := p.Get(1)
+	assert.Equal(t, ExpressionNode, expression.NodeType)
+	assert.Equal(t, " user.name ", expression.Data)
+	assert.Equal(t, ExpressionDelimiters{"{{", "}}"}, expression.Delimiters())
+	assert.Equal(t, "{{ user.name }}", expression.ExpressionSource())
+
+	// the tag-like content does not open an element
+	assert.Equal(t, "!", p.Get(2).Data)
+	assert.Equal(t, " if a<b ", p.Get(3).Data)
+	assert.Equal(t, "b", p.Get(4).NodeName())
+	assert.Equal(t, ExpressionNode, p.Get(4).NextSibling().NodeType)
+	assert.Equal(t, " endif ", p.Last().Data)
+
+	// positions are that of the source
+	assert.Equal(t, Position{Offset: 9, Line: 1, Column: 10}, expression.OpenTagRange().Start)
+	assert.Equal(t, Position{Offset: 24, Line: 1, Column: 25}, expression.OpenTagRange().End)
+
+	s, err := doc.String()
+	assert.NoError(t, err)
+	assert.Equal(t, html, s)
+	assert.Equal(t, "Hello {{ user.name }}!{% if a<b %}x{% endif %}", p.Text())
+}
+
+func TestExpressionsWithQuotes(t *testing.T) {
+	html := "<p>${ '}' + \"}}\" } {{ \"</p>\" }}</p>{{ unclosed"
+	doc := getTemplate(t, html)
+
+	assert.Equal(t, 2, doc.Length())
+	p := doc.First()
+	assert.Equal(t, 2, p.NumChildren())
+	assert.Equal(t, " '}' + \"}}\" ", p.First().Data)
+	assert.Equal(t, " \"</p>\" ", p.Last().Data)
+
+	// unclosed expressions are text
+	assert.Equal(t, TextNode, doc.Last().NodeType)
+	assert.Equal(t, "{{ unclosed", doc.Last().Data)
+
+	builder := strings.Builder{}
+	assert.NoError(t, doc.Render(&builder, RenderOptions{PreserveSource: true}))
+	assert.Equal(t, html, builder.String())
+}
+
+func TestExpressionsWithUnclosedQuotes(t *testing.T) {
+	html := "<p>{{ user's name }}</p><b>{{ a > b }}</b><i>{{ it's }}</i>"
+	doc := getTemplate(t, html)
+
+	// the expression ends at the first close delimiter
+	assert.Equal(t, 3, doc.Length())
+	p := doc.First()
+	assert.Equal(t, 1, p.NumChildren())
+	assert.Equal(t, " user's name ", p.First().Data)
+
+	// later expressions are still masked
+	b := doc.Get(1)
+	assert.Equal(t, 1, b.NumChildren())
+	assert.Equal(t, ExpressionNode, b.First().NodeType)
+	assert.Equal(t, " a > b ", b.First().Data)
+
+	// as is the last one, where the quote runs to the end
+	assert.Equal(t, " it's ", doc.Last().First().Data)
+
+	builder := strings.Builder{}
+	assert.NoError(t, doc.Render(&builder, RenderOptions{PreserveSource: true}))
+	assert.Equal(t, html, builder.String())
+}
+
+func TestExpressionsAreDropped(t *testing.T) {
+	reader := newExpressionReader(strings.NewReader("a {{ b }} c {{ d }}"), CommonExpressionDelimiters)
+	_, err := io.Copy(io.Discard, reader)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(reader.expressions))
+
+	reader.discard(9)
+	assert.Equal(t, 1, len(reader.expressions))
+	assert.Equal(t, "{{ d }}", reader.expressions[0].source)
+
+	reader.discard(18)
+	assert.Equal(t, 1, len(reader.expressions))
+	reader.discard(19)
+	assert.Equal(t, 0, len(reader.expressions))
+}
+
+func TestExpressionsInAttributes(t *testing.T) {
+	html := "<a href=\"/u/{{ user.id }}?a=1&amp;b=2\" title={{ \"a > b\" }} {{ attrs }} class='x'><!-- {{ c }} --></a>"
+	doc := getTemplate(t, html)
+
+	a := doc.First()
+	assert.Equal(t, 4, a.NumAttributes())
+	assert.Equal(t, "x", a.GetAttribute("class").Value)
+	assert.Equal(t, " {{ c }} ", a.First().Data)
+
+	href := a.GetAttribute("href")
+	assert.Equal(t, "/u/{{ user.id }}?a=1&b=2", href.Value)
+	assert.Equal(t, "/u/{{ user.id }}?a=1&amp;b=2", href.RawValue())
+	assert.True(t, href.HasExpressions())
+	assert.Equal(t, []ValuePart{
+		{Data: "/u/"},
+		{Data: " user.id ", IsExpression: true, Delimiters: ExpressionDelimiters{"{{", "}}"}},
+		{Data: "?a=1&b=2"},
+	}, href.ValueParts())
+
+	title := a.GetAttribute("title")
+	assert.Equal(t, "{{ \"a > b\" }}", title.Value)
+	assert.Equal(t, 1, len(title.ValueParts()))
+
+	// an attribute may be an expression on its own
+	assert.Equal(t, "{{ attrs }}", a.Attributes[2].Name)
+	assert.Equal(t, "", a.Attributes[2].Value)
+	assert.Equal(t, 0, len(a.Attributes[2].ValueParts()))
+	assert.False(t, a.GetAttribute("class").HasExpressions())
+
+	builder := strings.Builder{}
+	assert.NoError(t, doc.Render(&builder, RenderOptions{PreserveSource: true}))
+	assert.Equal(t, html, builder.String())
+}
+
+func TestExpressionsInRawText(t *testing.T) {
+	doc := getTemplate(t, "<script>var x = {{ \"</script>\" }};</script>")
+
+	script := doc.First()
+	assert.Equal(t, 3, script.NumChildren())
+	assert.Equal(t, "var x = ", script.First().Data)
+	assert.Equal(t, ExpressionNode, script.Get(1).NodeType)
+	assert.Equal(t, ";", script.Last().Data)
+}
+
+func TestExpressionsDisabled(t *testing.T) {
+	doc, err := getDoc("<p>{{ a }}</p>")
+	assert.NoError(t, err)
+	assert.Equal(t, TextNode, doc.First().First().NodeType)
+
+	attr := &HtmlAttribute{Name: "x", Value: "{{ a }}"}
+	assert.False(t, attr.HasExpressions())
+	assert.Equal(t, []ValuePart{{Data: "{{ a }}"}}, attr.ValueParts())
+}
+
+func TestNewExpression(t *testing.T) {
+	node := El("p").Children(NewText("Hi "), NewExpression(ExpressionDelimiters{"{{", "}}"}, "name")).Build()
+	assert.Equal(t, "<p>Hi {{name}}</p>", node.String())
+
+	// expressions are walked like any other node
+	count := 0
+	node.Traverse(func(node *HtmlNode) bool {
+		if node.NodeType == ExpressionNode {
+			count++
+		}
+		return true
+	})
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,171 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"errors"
+	"strings"
+)
+
+//
+// Defines where markup is inserted relative to a node when
+// using `InsertHtml()`.
+//
+type InsertPosition uint32
+
+// Enumeration
+const (
+	// Before the node itself, as its previous sibling.
+	BeforeBegin InsertPosition = iota
+
+	// Inside the node, before its first child.
+	AfterBegin
+
+	// Inside the node, after its last child.
+	BeforeEnd
+
+	// After the node itself, as its next sibling.
+	AfterEnd
+)
+
+//
+// Return the markup of all children of this node.
+//
+func (node *HtmlNode) InnerHtml() string {
+	builder := strings.Builder{}
+	for _, child := range node._children {
+		child.WriteToBuilder(&builder)
+	}
+
+	return builder.String()
+}
+
+//
+// Return the markup of this node, including its children. This
+// is the same as `String()`.
+//
+func (node *HtmlNode) OuterHtml() string {
+	return node.String()
+}
+
+//
+// Replace all children of this element with the nodes parsed from
+// the given markup. The markup is parsed with the options of this
+// node, and within the context of this node, so that the content of
+// elements like `script` is kept as text.
+//
+// Returns an error if this is not an element node, or the markup
+// could not be parsed.
+//
+func (node *HtmlNode) SetInnerHtml(html string) error {
+	if node.NodeType != ElementNode {
+		return errors.New("Inner html can only be set on element nodes")
+	}
+
+	nodes, err := node.parseFragment(html, node)
+	if err != nil {
+		return err
+	}
+
+	node.RemoveAllChildren()
+	for _, child := range nodes {
+		node.InsertChildAt(node.NumChildren(), child)
+	}
+
+	return nil
+}
+
+//
+// Parse the given markup and insert the resulting nodes at the given
+// position relative to this node. The markup is parsed with the
+// options of this node, and within the context of the element the
+// nodes are inserted in.
+//
+// Returns an error if the nodes cannot be inserted at the given
+// position, or the markup could not be parsed.
+//
+func (node *HtmlNode) InsertHtml(position InsertPosition, html string) error {
+	switch position {
+	case AfterBegin, BeforeEnd:
+		if node.NodeType != ElementNode {
+			return errors.New("Html can only be inserted inside element nodes")
+		}
+
+		nodes, err := node.parseFragment(html, node)
+		if err != nil {
+			return err
+		}
+
+		index := 0
+		if position == BeforeEnd {
+			index = node.NumChildren()
+		}
+
+		for _, child := range nodes {
+			node.InsertChildAt(index, child)
+			index++
+		}
+
+	case BeforeBegin, AfterEnd:
+		if node._parent == nil && node._wrappingElements == nil {
+			return errors.New("Html cannot be inserted next to a detached node")
+		}
+
+		nodes, err := node.parseFragment(html, node._parent)
+		if err != nil {
+			return err
+		}
+
+		anchor := node
+		for _, sibling := range nodes {
+			if position == BeforeBegin {
+				node.InsertBeforeMe(sibling)
+			} else {
+				anchor.InsertAfterMe(sibling)
+				anchor = sibling
+			}
+		}
+
+	default:
+		return errors.New("Unknown position to insert html at")
+	}
+
+	return nil
+}
+
+//
+// Parse the given markup, using the options of this node, as the
+// content of the given context element. Returns the parsed nodes
+// at the top level.
+//
+func (node *HtmlNode) parseFragment(html string, context *HtmlNode) ([]*HtmlNode, error) {
+	// content of raw text elements is never parsed
+	options := node.options()
+	if context != nil && options.isRawTextElement(context._tagName) {
+		if html == "" {
+			return nil, nil
+		}
+
+		text := newTextNode(html, decodeRawText(context._tagName, html), options)
+		text._options = node._options
+		return []*HtmlNode{text}, nil
+	}
+
+	elements, err := ParseWithOptions(strings.NewReader(html), options)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*HtmlNode, 0, elements.Length())
+	nodes = append(nodes, elements.list().nodes...)
+	return nodes, nil
+}
@@ -0,0 +1,118 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInnerAndOuterHtml(t *testing.T) {
+	doc, err := getDoc("<div class='a'><p>Hello</p> <b>World</b></div>")
+	assert.NoError(t, err)
+
+	div := doc.First()
+	assert.Equal(t, "<p>Hello</p><b>World</b>", div.InnerHtml())
+	assert.Equal(t, "<div class=\"a\"><p>Hello</p><b>World</b></div>", div.OuterHtml())
+	assert.Equal(t, "", NewElement("br").InnerHtml())
+}
+
+func TestSetInnerHtml(t *testing.T) {
+	doc, err := getDoc("<div><p>old</p></div>")
+	assert.NoError(t, err)
+
+	div := doc.First()
+	old := div.First()
+	assert.NoError(t, div.SetInnerHtml("<custom:Widget a='1'>x</custom:Widget>text<br></div><i>"))
+	assert.Equal(t, "<div><custom:Widget a=\"1\">x</custom:Widget>text<br><i></i></div>", div.String())
+	assert.Nil(t, old.Parent())
+	assert.NoError(t, doc.checkInvariants())
+
+	// content of raw text elements is not parsed
+	script := NewElement("script")
+	assert.NoError(t, script.SetInnerHtml("if (a < b) { x = '<b>'; }"))
+	assert.Equal(t, 1, script.NumChildren())
+	assert.Equal(t, "<script>if (a < b) { x = '<b>'; }</script>", script.String())
+
+	// clearing the content
+	assert.NoError(t, div.SetInnerHtml(""))
+	assert.False(t, div.HasChildren())
+
+	// only elements have inner html
+	assert.Error(t, NewText("x").SetInnerHtml("<b></b>"))
+}
+
+func TestSetInnerHtmlUsesNodeOptions(t *testing.T) {
+	options := getDefaultOptions()
+	options.CaseSensitiveAttributes = true
+	doc, err := ParseWithOptions(strings.NewReader("<div></div>"), options)
+	assert.NoError(t, err)
+
+	div := doc.First()
+	assert.NoError(t, div.SetInnerHtml("<span onClick='x'></span>"))
+	assert.False(t, div.First().HasAttribute("onclick"))
+	assert.True(t, div.First().HasAttribute("onClick"))
+
+	// failing options are honored
+	options.EndTagRecovery = FailOnMismatchedEndTag
+	assert.Error(t, div.SetInnerHtml("<b></i>"))
+	assert.True(t, div.HasChildren())
+}
+
+func TestInsertHtml(t *testing.T) {
+	doc, err := getDoc("<ul><li>2</li></ul>")
+	assert.NoError(t, err)
+
+	ul := doc.First()
+	li := ul.First()
+	assert.NoError(t, li.InsertHtml(BeforeBegin, "<li>0</li><li>1</li>"))
+	assert.NoError(t, li.InsertHtml(AfterEnd, "<li>3</li><li>4</li>"))
+	assert.NoError(t, ul.InsertHtml(AfterBegin, "<!-- start -->"))
+	assert.NoError(t, ul.InsertHtml(BeforeEnd, "<li>5</li>end"))
+	assert.NoError(t, li.InsertHtml(AfterBegin, "<b>"))
+	assert.NoError(t, li.InsertHtml(BeforeEnd, "!"))
+	assert.Equal(t, "<ul><!-- start --><li>0</li><li>1</li><li><b></b>2!</li><li>3</li><li>4</li><li>5</li>end</ul>", ul.String())
+	assert.NoError(t, doc.checkInvariants())
+
+	// top-level nodes
+	assert.NoError(t, ul.InsertHtml(BeforeBegin, "<h1>Title</h1>"))
+	assert.NoError(t, ul.InsertHtml(AfterEnd, "<p>a</p><p>b</p>"))
+	assert.Equal(t, 4, doc.Length())
+	assert.Equal(t, "p", doc.Last().NodeName())
+	assert.Equal(t, "a", doc.Get(2).First().Data)
+	assert.NoError(t, doc.checkInvariants())
+}
+
+func TestInsertHtmlErrors(t *testing.T) {
+	// detached nodes have no siblings
+	node := NewElement("div")
+	assert.Error(t, node.InsertHtml(BeforeBegin, "<b></b>"))
+	assert.Error(t, node.InsertHtml(AfterEnd, "<b></b>"))
+
+	// text nodes have no children
+	text := NewText("x")
+	node.InsertChildAt(0, text)
+	assert.Error(t, text.InsertHtml(AfterBegin, "<b></b>"))
+	assert.Error(t, text.InsertHtml(BeforeEnd, "<b></b>"))
+	assert.NoError(t, text.InsertHtml(AfterEnd, "<b></b>"))
+	assert.Equal(t, "<div>x<b></b></div>", node.String())
+
+	assert.Error(t, node.InsertHtml(InsertPosition(10), "<b></b>"))
+
+	// raw text context of the parent
+	script := NewElement("script")
+	script.InsertChildAt(0, NewText("a();"))
+	assert.NoError(t, script.First().InsertHtml(AfterEnd, "<b>"))
+	assert.Equal(t, "<script>a();<b></script>", script.String())
+}
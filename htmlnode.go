@@ -28,6 +28,7 @@ const (
 	ElementNode
 	CommentNode
 	DoctypeNode
+	ExpressionNode
 )
 
 //
@@ -36,14 +37,23 @@ const (
 // property you are reading will contain a value or not.
 //
 type HtmlNode struct {
-	_tagName          string
+	_tagName          string    // the normalized, lower-cased, tag name
+	_rawTagName       string    // the tag name as spelled in source
 	_parent           *HtmlNode `json:"-"`
 	Attributes        []*HtmlAttribute
 	_children         []*HtmlNode
-	IsSelfClosing     bool
+	IsSelfClosing     bool // whether the tag was written as `<x />`
+	IsVoid            bool // whether this is a void element that has no content, such as `<br>`
 	NodeType          HtmlNodeType
 	Data              string
-	_wrappingElements *HtmlElements // the document node that this node belongs to
+	_wrappingElements *HtmlElements        // the document node that this node belongs to
+	_openTag          Range                // where the open tag, or the text, of this node is in source
+	_closeTag         Range                // where the close tag of this node is in source
+	_options          *ParseOptions        // the options this node was parsed with
+	_source           *nodeSource          // the raw source this node was parsed from
+	_rawData          string               // the text as spelled in source, for text nodes
+	_decodedData      string               // the text with character references decoded, for text nodes
+	_delimiters       ExpressionDelimiters // the delimiters, for expression nodes
 }
 
 func newNode(name string) *HtmlNode {
@@ -52,16 +62,79 @@ func newNode(name string) *HtmlNode {
 	}
 }
 
+//
+// Create a new element node with the given tag name. The name is
+// normalized the same way as when parsing, and the spelling given
+// is kept as the raw name. The element is marked as void if it is
+// one of the `DefaultVoidElements()`.
+//
+func NewElement(name string) *HtmlNode {
+	tagName := strings.ToLower(name)
+	return &HtmlNode{
+		_tagName:    tagName,
+		_rawTagName: name,
+		NodeType:    ElementNode,
+		IsVoid:      defaultVoidElementSet[tagName],
+	}
+}
+
+//
+// Create a new text node with the given text. The text is not
+// escaped, it is escaped when the node is written as markup.
+//
+func NewText(text string) *HtmlNode {
+	return &HtmlNode{
+		NodeType: TextNode,
+		Data:     text,
+	}
+}
+
+//
+// Create a new comment node with the given comment, which is the
+// text between `<!--` and `-->`.
+//
+func NewComment(comment string) *HtmlNode {
+	return &HtmlNode{
+		NodeType: CommentNode,
+		Data:     comment,
+	}
+}
+
+//
+// Create a new doctype node with the given doctype, such as `html`.
+//
+func NewDoctype(doctype string) *HtmlNode {
+	return &HtmlNode{
+		NodeType: DoctypeNode,
+		Data:     doctype,
+	}
+}
+
 //----- basic property accessors
 
 //
 // Return the node name, also known as tag name for
-// this element.
+// this element. For parsed elements this is the normalized,
+// lower-cased, name. Use `RawNodeName()` to get the name as
+// it was spelled in source.
 //
 func (node *HtmlNode) NodeName() string {
 	return strings.TrimSpace(node._tagName)
 }
 
+//
+// Return the node name as it was spelled in source, such as
+// `custom:PageBody`. For nodes that were not parsed from source
+// this is the same as `NodeName()`.
+//
+func (node *HtmlNode) RawNodeName() string {
+	if node._rawTagName == "" {
+		return node.NodeName()
+	}
+
+	return node._rawTagName
+}
+
 //
 // Return the total number of children this node has.
 //
@@ -90,6 +163,15 @@ func (node *HtmlNode) Children() []*HtmlNode {
 	return node._children
 }
 
+//
+// Check if this is a text node that contains only whitespace.
+// Such nodes are only kept when parsing with the option to
+// `PreserveWhitespace`.
+//
+func (node *HtmlNode) IsWhitespace() bool {
+	return node.NodeType == TextNode && isWhitespaceOnly(node.Data)
+}
+
 //
 // Quick check to see if this node has any children
 // or not.
@@ -102,6 +184,49 @@ func (node *HtmlNode) HasChildren() bool {
 	return true
 }
 
+//----- source positions
+
+//
+// Return where the open tag of this element is in the parsed
+// source. For text, comment and doctype nodes this is where
+// the entire node is. The range is not valid for nodes that
+// were not parsed from source.
+//
+func (node *HtmlNode) OpenTagRange() Range {
+	return node._openTag
+}
+
+//
+// Return where the close tag of this element is in the parsed
+// source. If the element was self-closing, or was closed implicitly,
+// the range is empty and points to where the element ended. The
+// range is not valid for nodes that were not parsed from source.
+//
+func (node *HtmlNode) CloseTagRange() Range {
+	return node._closeTag
+}
+
+//
+// Return where this node is in the parsed source, starting at
+// its open tag and ending at its close tag. The range is not
+// valid for nodes that were not parsed from source.
+//
+func (node *HtmlNode) SourceRange() Range {
+	if !node._openTag.IsValid() {
+		return Range{}
+	}
+
+	end := node._closeTag.End
+	if !end.IsValid() {
+		end = node._openTag.End
+	}
+
+	return Range{
+		Start: node._openTag.Start,
+		End:   end,
+	}
+}
+
 //----- FIND methods
 
 //
@@ -168,8 +293,9 @@ func (node *HtmlNode) getElementsByNameInternal(name string, elements *HtmlEleme
 	name = strings.TrimSpace(name)
 	name = strings.ToLower(name)
 
+	// collect without attaching the node to the result
 	if node._tagName == name {
-		elements.appendNode(node)
+		elements.collect(node)
 	}
 
 	if !node.HasChildren() {
@@ -238,10 +364,6 @@ func (node *HtmlNode) GetChildBefore(child *HtmlNode) *HtmlNode {
 		}
 	}
 
-	if node._wrappingElements != nil {
-		node._wrappingElements.GetBefore(node)
-	}
-
 	return nil
 }
 
@@ -265,20 +387,22 @@ func (node *HtmlNode) GetChildAfter(child *HtmlNode) *HtmlNode {
 		}
 	}
 
-	if node._wrappingElements != nil {
-		node._wrappingElements.GetAfter(node)
-	}
-
 	return nil
 }
 
 //
 // Return the node before this node in the list. Returns
 // `nil` if this node is detached, or has no previous sibling.
+// Nodes at the top level of a document are siblings of each
+// other.
 //
 func (node *HtmlNode) PrevSibling() *HtmlNode {
 	if node._parent != nil {
-		return node._parent.GetChildAfter(node)
+		return node._parent.GetChildBefore(node)
+	}
+
+	if node._wrappingElements != nil {
+		return node._wrappingElements.GetBefore(node)
 	}
 
 	return nil
@@ -287,25 +411,36 @@ func (node *HtmlNode) PrevSibling() *HtmlNode {
 //
 // Return the node after this node in the list. Returns
 // `nil` if this node is detached, or has no next sibling.
+// Nodes at the top level of a document are siblings of each
+// other.
 //
 func (node *HtmlNode) NextSibling() *HtmlNode {
 	if node._parent != nil {
 		return node._parent.GetChildAfter(node)
 	}
 
+	if node._wrappingElements != nil {
+		return node._wrappingElements.GetAfter(node)
+	}
+
 	return nil
 }
 
 //----- Manipulation methods
 
 //
-// Remove all children from this `HtmlNode`.
+// Remove all children from this `HtmlNode`. All removed
+// children are detached.
 //
 func (node *HtmlNode) RemoveAllChildren() {
 	if !node.HasChildren() {
 		return
 	}
 
+	for _, child := range node._children {
+		child.detach()
+	}
+
 	node._children = make([]*HtmlNode, 0)
 }
 
@@ -362,6 +497,10 @@ func (node *HtmlNode) ReplaceMe(replacement *HtmlNode) bool {
 	}
 
 	if node._parent == nil {
+		if node._wrappingElements == nil {
+			return false
+		}
+
 		return node._wrappingElements.Replace(node, replacement)
 	}
 
@@ -369,7 +508,8 @@ func (node *HtmlNode) ReplaceMe(replacement *HtmlNode) bool {
 }
 
 //
-// Replace a child of this node with given replacement.
+// Replace a child of this node with given replacement. The
+// replacement is detached from where it was attached before.
 //
 // Returns `true` if the node was actually replaced, `false`
 // otherwise
@@ -379,7 +519,7 @@ func (node *HtmlNode) ReplaceChild(original *HtmlNode, replacement *HtmlNode) bo
 		return false
 	}
 
-	if replacement == nil {
+	if !node.canAdopt(replacement) {
 		return false
 	}
 
@@ -387,87 +527,92 @@ func (node *HtmlNode) ReplaceChild(original *HtmlNode, replacement *HtmlNode) bo
 		return false
 	}
 
-	for index, child := range node._children {
-		if child == original {
-			original.detach()
-			node._children[index] = replacement
-			return true
-		}
+	if indexOfNode(node._children, original, 0) < 0 {
+		return false
 	}
 
-	return false
+	if original == replacement {
+		return true
+	}
+
+	replacement.RemoveMe()
+	index := indexOfNode(node._children, original, 0)
+	original.detach()
+	node._children[index] = replacement
+	node.adopt(replacement)
+	return true
 }
 
 //
 // Insert the child at the given index. If the index is less than zero
 // the node is inserted as the first node. If the index is greater than
-// the last node index it is inserted as the last node.
+// the last node index it is inserted as the last node. The node is
+// detached from where it was attached before. Nothing is inserted if
+// the node is `nil`, or is this node or one of its ancestors.
 //
 func (node *HtmlNode) InsertChildAt(index int, additional *HtmlNode) {
-	// first addition
-	if index <= 0 {
-		node._children = append([]*HtmlNode{additional}, node._children...)
+	if !node.canAdopt(additional) {
 		return
 	}
 
-	// falls at the end
-	num := len(node._children)
-	if index >= num {
-		node._children = append(node._children, additional)
-		return
+	// moving a child forward within this node shifts the index
+	if additional._parent == node {
+		current := indexOfNode(node._children, additional, 0)
+		if current >= 0 && current < index {
+			index--
+		}
 	}
 
-	// falls in between
-	prefix := node._children[:index]
-	suffix := node._children[index:]
-	node._children = append(prefix, additional)
-	node._children = append(node._children, suffix...)
-	return
+	additional.RemoveMe()
+	node.insertChildAt(index, additional)
 }
 
 //
 // Insert a node before given child. Returns `true` if the node
 // is inserted. Returns `false` if the node has no children, or
-// the given child does not belong to this node.
+// the given child does not belong to this node, or the node cannot
+// be added to this node.
 //
 func (node *HtmlNode) InsertBeforeChild(child *HtmlNode, additional *HtmlNode) bool {
-	if !node.HasChildren() {
+	if !node.HasChildren() || !node.canAdopt(additional) {
 		return false
 	}
 
-	for index, kid := range node._children {
-		if kid == child {
-			newIndex := index - 1
-			if newIndex == -1 {
-				newIndex = 0
-			}
+	if indexOfNode(node._children, child, 0) < 0 {
+		return false
+	}
 
-			node.InsertChildAt(newIndex, additional)
-			return true
-		}
+	if child == additional {
+		return true
 	}
 
-	return false
+	additional.RemoveMe()
+	node.insertChildAt(indexOfNode(node._children, child, 0), additional)
+	return true
 }
 
 //
 // Insert a node after given child. Returns `true` if the node
 // is inserted. Returns `false` if the node has no children, or
-// the given child does not belong to this node.
+// the given child does not belong to this node, or the node cannot
+// be added to this node.
 //
 func (node *HtmlNode) InsertAfterChild(child *HtmlNode, additional *HtmlNode) bool {
-	if !node.HasChildren() {
+	if !node.HasChildren() || !node.canAdopt(additional) {
 		return false
 	}
 
-	for index, kid := range node._children {
-		if kid == child {
-			node.InsertChildAt(index+1, additional)
-			return true
-		}
+	if indexOfNode(node._children, child, 0) < 0 {
+		return false
 	}
 
-	return false
+	if child == additional {
+		return true
+	}
+
+	additional.RemoveMe()
+	node.insertChildAt(indexOfNode(node._children, child, 0)+1, additional)
+	return true
 }
 
 //
@@ -504,55 +649,74 @@ func (node *HtmlNode) InsertAfterMe(additional *HtmlNode) bool {
 	return false
 }
 
-//----- tostring()
+//----- Copy methods
 
-func (node *HtmlNode) String() string {
-	builder := strings.Builder{}
-	node.WriteToBuilder(&builder)
-	return builder.String()
-}
+//
+// Return a copy of this node. The copy has its own attributes, and
+// is detached from any parent or document. If `deep` is `true`, all
+// children are copied as well, otherwise the copy has no children.
+//
+func (node *HtmlNode) Clone(deep bool) *HtmlNode {
+	clone := *node
+	clone._parent = nil
+	clone._wrappingElements = nil
+	clone._children = nil
 
-func (node *HtmlNode) WriteToBuilder(builder *strings.Builder) {
-	if node.NodeType == DoctypeNode || node.NodeType == TextNode || node.NodeType == CommentNode {
-		builder.WriteString("**")
-		builder.WriteString(node.Data)
-		return
+	if node._source != nil {
+		clone._source = node._source.clone()
 	}
 
-	builder.WriteString("<")
-	builder.WriteString(node.NodeName())
-
-	// attributes
-	if node.ContainsAttributes() {
+	if node.Attributes != nil {
+		clone.Attributes = make([]*HtmlAttribute, 0, len(node.Attributes))
 		for _, attr := range node.Attributes {
-			builder.WriteString(" ")
-			builder.WriteString(attr.Name)
-			builder.WriteString("=\"")
-			builder.WriteString(attr.Value)
-			builder.WriteString("\"")
+			copied := *attr
+			copied._owner = &clone
+			clone.Attributes = append(clone.Attributes, &copied)
 		}
 	}
 
-	// self-closing?
-	if !node.HasChildren() {
-		builder.WriteString(" />")
-	} else {
-		builder.WriteString(">\n")
+	if deep && node.HasChildren() {
+		clone._children = make([]*HtmlNode, 0, len(node._children))
 		for _, child := range node._children {
-			child.WriteToBuilder(builder)
+			copied := child.Clone(true)
+			copied._parent = &clone
+			clone._children = append(clone._children, copied)
 		}
-
-		// close
-		builder.WriteString("</")
-		builder.WriteString(node.NodeName())
-		builder.WriteString(">\n")
 	}
 
-	builder.WriteString(" ")
+	return &clone
+}
+
+//----- tostring()
+
+func (node *HtmlNode) String() string {
+	builder := strings.Builder{}
+	node.WriteToBuilder(&builder)
+	return builder.String()
+}
+
+//
+// Write this node as markup to the given builder. See `Render()`
+// to control how the markup is written.
+//
+func (node *HtmlNode) WriteToBuilder(builder *strings.Builder) {
+	node.Render(builder, RenderOptions{})
 }
 
 //----- Internal methods
 
+//
+// Return the options this node was parsed with, or the default
+// options if the node was not created by parsing.
+//
+func (node *HtmlNode) options() *ParseOptions {
+	if node._options == nil {
+		return getDefaultOptions()
+	}
+
+	return node._options
+}
+
 //
 // Add a child node to this node.
 //
@@ -564,6 +728,57 @@ func (node *HtmlNode) addChild(child *HtmlNode) {
 	node._children = append(node._children, child)
 }
 
+//
+// Insert the given detached node at the given index, clamping the
+// index to the bounds of the children.
+//
+func (node *HtmlNode) insertChildAt(index int, additional *HtmlNode) {
+	num := len(node._children)
+	if index < 0 {
+		index = 0
+	}
+	if index > num {
+		index = num
+	}
+
+	// we copy the nodes so that we do not overwrite the
+	// suffix that shares the same backing array
+	updated := make([]*HtmlNode, 0, num+1)
+	updated = append(updated, node._children[:index]...)
+	updated = append(updated, additional)
+	node._children = append(updated, node._children[index:]...)
+	node.adopt(additional)
+}
+
+//
+// Check if the given node can be added as a child of this node,
+// that is it is not `nil` and does not create a cycle.
+//
+func (node *HtmlNode) canAdopt(additional *HtmlNode) bool {
+	return additional != nil && !additional.isAncestorOf(node)
+}
+
+//
+// Check if this node is the given node, or one of its ancestors.
+//
+func (node *HtmlNode) isAncestorOf(other *HtmlNode) bool {
+	for ; other != nil; other = other._parent {
+		if other == node {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// Set the links of the given child that was just added to this node.
+//
+func (node *HtmlNode) adopt(child *HtmlNode) {
+	child._parent = node
+	child._wrappingElements = nil
+}
+
 //
 // Detach the given node. Remove its associated with its
 // parent or its wrapping element.
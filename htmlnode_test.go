@@ -36,7 +36,7 @@ func TestNodeRemoveAllChildren(t *testing.T) {
 	doc, err := getDoc("<html />")
 	assert.NoError(t, err)
 
-	doc.nodes[0].RemoveAllChildren()
+	doc.Nodes()[0].RemoveAllChildren()
 
 	// if you have kids
 	doc, err = getDoc("<html><head /><body /></html>")
@@ -131,3 +131,219 @@ func TestNodeGetElementsByName(t *testing.T) {
 	assert.NotNil(t, doc.First().GetElementsByName("head"))
 	assert.NotNil(t, doc.First().GetElementsByName("HEAD"))
 }
+
+func TestRawNodeName(t *testing.T) {
+	doc, err := getDoc("<html class='test1' custom:Title='hello'>Hello World <custom:PageBody /></HTML>")
+	assert.NoError(t, err)
+
+	html := doc.First()
+	assert.Equal(t, "html", html.NodeName())
+	assert.Equal(t, "html", html.RawNodeName())
+
+	body := html.Get(1)
+	assert.Equal(t, "custom:pagebody", body.NodeName())
+	assert.Equal(t, "custom:PageBody", body.RawNodeName())
+	assert.Equal(t, 1, html.GetElementsByName("custom:PageBody").Length())
+
+	// nodes not created by parsing
+	assert.Equal(t, "a1", newNode("a1").RawNodeName())
+}
+
+func TestCloneNode(t *testing.T) {
+	doc, err := getDoc("<html><body class='a'><p id='x'>Hello <b>World</b></p></body></html>")
+	assert.NoError(t, err)
+
+	body := doc.First().First()
+	clone := body.Clone(true)
+	assert.Equal(t, body.String(), clone.String())
+	assert.Nil(t, clone.Parent())
+	assert.Nil(t, clone._wrappingElements)
+
+	// links within the copy point to the copy
+	p := clone.First()
+	assert.Same(t, clone, p.Parent())
+	assert.Same(t, p, p.Get(1).Parent())
+
+	// no state is shared with the original
+	clone.SetAttribute("class", "b")
+	p.SetAttribute("id", "y")
+	p.Get(1).First().Data = "Copy"
+	p.RemoveChild(p.First())
+	assert.Equal(t, "<body class=\"a\"><p id=\"x\">Hello <b>World</b></p></body>", body.String())
+	assert.Equal(t, "<body class=\"b\"><p id=\"y\"><b>Copy</b></p></body>", clone.String())
+
+	// shallow copy
+	shallow := body.Clone(false)
+	assert.False(t, shallow.HasChildren())
+	assert.Equal(t, "<body class=\"a\"></body>", shallow.String())
+	assert.Equal(t, 1, body.NumChildren())
+}
+
+func TestCloneNodePreservesSource(t *testing.T) {
+	html := "<div CLASS = 'a'>\n  <br>text</div>"
+	doc, err := getDoc(html)
+	assert.NoError(t, err)
+
+	builder := strings.Builder{}
+	clone := doc.First().Clone(true)
+	assert.NoError(t, clone.Render(&builder, RenderOptions{PreserveSource: true}))
+	assert.Equal(t, html, builder.String())
+
+	// the clone has its own copy of the source
+	assert.NotSame(t, doc.First()._source, clone._source)
+	clone._source.attributes[0].Value = "b"
+	assert.Equal(t, "a", doc.First()._source.attributes[0].Value)
+}
+
+func TestNodeConstructors(t *testing.T) {
+	element := NewElement("custom:PageBody")
+	assert.Equal(t, ElementNode, element.NodeType)
+	assert.Equal(t, "custom:pagebody", element.NodeName())
+	assert.Equal(t, "custom:PageBody", element.RawNodeName())
+	assert.False(t, element.IsVoid)
+	assert.True(t, NewElement("IMG").IsVoid)
+	assert.Equal(t, "<IMG>", NewElement("IMG").String())
+
+	text := NewText("a < b")
+	assert.Equal(t, TextNode, text.NodeType)
+	assert.Equal(t, "a &lt; b", text.String())
+
+	comment := NewComment(" c ")
+	assert.Equal(t, CommentNode, comment.NodeType)
+	assert.Equal(t, "<!-- c -->", comment.String())
+
+	doctype := NewDoctype("html")
+	assert.Equal(t, DoctypeNode, doctype.NodeType)
+	assert.Equal(t, "<!DOCTYPE html>", doctype.String())
+}
+
+func TestNodeInsertMovesNode(t *testing.T) {
+	doc, err := getDoc("<div id='a'><p>1</p><p>2</p></div><div id='b'><span></span></div>")
+	assert.NoError(t, err)
+
+	a := doc.GetElementById("a")
+	b := doc.GetElementById("b")
+	first := a.First()
+
+	// moving to another parent detaches from the old one
+	b.InsertChildAt(0, first)
+	assert.Equal(t, 1, a.NumChildren())
+	assert.Same(t, b, first.Parent())
+	assert.NoError(t, doc.checkInvariants())
+
+	// moving within the same parent
+	span := b.Get(1)
+	b.InsertChildAt(5, first)
+	assert.Same(t, span, b.First())
+	assert.Same(t, first, b.Last())
+	b.InsertChildAt(0, first)
+	assert.Same(t, first, b.First())
+	assert.NoError(t, doc.checkInvariants())
+
+	// moving a top-level node into another node
+	assert.True(t, a.InsertAfterChild(a.First(), b))
+	assert.Equal(t, 1, doc.Length())
+	assert.Same(t, a, b.Parent())
+	assert.Nil(t, b._wrappingElements)
+	assert.NoError(t, doc.checkInvariants())
+
+	s, _ := doc.String()
+	assert.Equal(t, "<div id=\"a\"><p>2</p><div id=\"b\"><p>1</p><span></span></div></div>", s)
+}
+
+func TestNodeInsertBeforeAndAfterChild(t *testing.T) {
+	doc, err := getDoc("<ul><li>1</li><li>2</li><li>3</li></ul>")
+	assert.NoError(t, err)
+
+	ul := doc.First()
+	second := ul.Get(1)
+
+	assert.True(t, ul.InsertBeforeChild(second, NewText("a")))
+	assert.True(t, ul.InsertAfterChild(second, NewText("b")))
+	assert.True(t, ul.InsertBeforeChild(ul.First(), NewText("c")))
+	assert.True(t, ul.InsertBeforeChild(second, second))
+	assert.Equal(t, "<ul>c<li>1</li>a<li>2</li>b<li>3</li></ul>", ul.String())
+
+	// move the last item before the first
+	assert.True(t, ul.Last().InsertBeforeMe(NewText("d")))
+	assert.True(t, ul.InsertBeforeChild(ul.Get(1), ul.Last()))
+	assert.Equal(t, "<ul>c<li>3</li><li>1</li>a<li>2</li>bd</ul>", ul.String())
+	assert.NoError(t, doc.checkInvariants())
+
+	// not a child
+	assert.False(t, ul.InsertBeforeChild(NewText("x"), NewText("y")))
+	assert.False(t, ul.InsertAfterChild(NewText("x"), NewText("y")))
+	assert.False(t, ul.InsertAfterChild(second, nil))
+}
+
+func TestNodeInsertRejectsCycles(t *testing.T) {
+	doc, err := getDoc("<div><p><b>x</b></p></div>")
+	assert.NoError(t, err)
+
+	div := doc.First()
+	p := div.First()
+	b := p.First()
+
+	b.InsertChildAt(0, div)
+	b.InsertChildAt(0, b)
+	assert.False(t, p.InsertAfterChild(b, div))
+	assert.False(t, p.ReplaceChild(b, p))
+	assert.False(t, b.InsertAfterMe(p))
+	p.InsertChildAt(0, nil)
+	assert.Equal(t, 1, b.NumChildren())
+	assert.Equal(t, 1, p.NumChildren())
+	assert.NoError(t, doc.checkInvariants())
+	assert.Equal(t, "<div><p><b>x</b></p></div>", div.String())
+}
+
+func TestNodeReplaceChildMovesNode(t *testing.T) {
+	doc, err := getDoc("<div><p>1</p><p>2</p></div><span></span>")
+	assert.NoError(t, err)
+
+	div := doc.First()
+	span := doc.Get(1)
+	first := div.First()
+
+	assert.True(t, div.ReplaceChild(div.Last(), first))
+	assert.Equal(t, "<div><p>1</p></div>", div.String())
+
+	assert.True(t, first.ReplaceMe(span))
+	assert.Equal(t, 1, doc.Length())
+	assert.Same(t, div, span.Parent())
+	assert.Nil(t, first.Parent())
+	assert.NoError(t, doc.checkInvariants())
+	assert.NoError(t, first.checkInvariants())
+
+	// replacing a detached node
+	assert.False(t, first.ReplaceMe(NewText("x")))
+}
+
+func TestNodeSiblings(t *testing.T) {
+	doc, err := getDoc("<a></a><div><p>1</p><p>2</p><p>3</p></div>")
+	assert.NoError(t, err)
+
+	div := doc.Get(1)
+	second := div.Get(1)
+	assert.Same(t, div.First(), second.PrevSibling())
+	assert.Same(t, div.Last(), second.NextSibling())
+	assert.Nil(t, div.First().PrevSibling())
+	assert.Nil(t, div.Last().NextSibling())
+
+	// top-level nodes
+	assert.Same(t, doc.First(), div.PrevSibling())
+	assert.Nil(t, div.NextSibling())
+
+	// detached nodes
+	assert.Nil(t, NewText("x").PrevSibling())
+	assert.Nil(t, NewText("x").NextSibling())
+}
+
+func TestNodeRemoveAllChildrenDetaches(t *testing.T) {
+	doc, err := getDoc("<div><p>1</p></div>")
+	assert.NoError(t, err)
+
+	p := doc.First().First()
+	doc.First().RemoveAllChildren()
+	assert.Nil(t, p.Parent())
+	assert.False(t, p.RemoveMe())
+}
@@ -0,0 +1,121 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"errors"
+	"strconv"
+)
+
+//
+// Check that all nodes within this list of elements are linked
+// consistently:
+//
+//   - top-level nodes have no parent, and are wrapped by this list
+//   - children have this node as parent, and are not wrapped
+//   - no node is `nil`, or appears more than once in the tree
+//
+// Returns an error describing the first problem found, or `nil`
+// if the tree is consistent. This is meant to be used in tests.
+//
+func (elements *HtmlElements) checkInvariants() error {
+	seen := make(map[*HtmlNode]bool)
+	for index, node := range elements.list().nodes {
+		if node == nil {
+			return errors.New("Node at index " + strconv.Itoa(index) + " is nil")
+		}
+
+		if node._parent != nil {
+			return errors.New("Top-level node '" + describeNode(node) + "' has a parent")
+		}
+
+		if !elements.wraps(node) {
+			return errors.New("Top-level node '" + describeNode(node) + "' is not wrapped by its elements")
+		}
+
+		err := node.checkChildren(seen)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//
+// Check that this node, and all nodes below it, are linked
+// consistently. See `HtmlElements.checkInvariants()`.
+//
+func (node *HtmlNode) checkInvariants() error {
+	if node._parent != nil && indexOfNode(node._parent._children, node, 0) < 0 {
+		return errors.New("Node '" + describeNode(node) + "' is not a child of its parent")
+	}
+
+	if node._wrappingElements != nil && indexOfNode(node._wrappingElements.list().nodes, node, 0) < 0 {
+		return errors.New("Node '" + describeNode(node) + "' is not a node of its elements")
+	}
+
+	return node.checkChildren(make(map[*HtmlNode]bool))
+}
+
+//
+// Check the links of all children of this node, recording all
+// nodes seen so far.
+//
+func (node *HtmlNode) checkChildren(seen map[*HtmlNode]bool) error {
+	if seen[node] {
+		return errors.New("Node '" + describeNode(node) + "' appears more than once in the tree")
+	}
+	seen[node] = true
+
+	for index, child := range node._children {
+		if child == nil {
+			return errors.New("Child at index " + strconv.Itoa(index) + " of '" + describeNode(node) + "' is nil")
+		}
+
+		if child._parent != node {
+			return errors.New("Child '" + describeNode(child) + "' of '" + describeNode(node) + "' has a different parent")
+		}
+
+		if child._wrappingElements != nil {
+			return errors.New("Child '" + describeNode(child) + "' of '" + describeNode(node) + "' is wrapped by elements")
+		}
+
+		err := child.checkChildren(seen)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//
+// Return a short description of the node for messages.
+//
+func describeNode(node *HtmlNode) string {
+	switch node.NodeType {
+	case TextNode:
+		return "#text"
+
+	case CommentNode:
+		return "#comment"
+
+	case DoctypeNode:
+		return "#doctype"
+
+	case ExpressionNode:
+		return "#expression"
+	}
+
+	return node.RawNodeName()
+}
@@ -0,0 +1,68 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvariantsOfParsedTree(t *testing.T) {
+	doc, err := getDoc("<!DOCTYPE html><html><head><title>x</title></head><body><p>a<br>b</div></body></html><!-- c -->")
+	assert.NoError(t, err)
+	assert.NoError(t, doc.checkInvariants())
+
+	for _, node := range doc.Nodes() {
+		assert.NoError(t, node.checkInvariants())
+	}
+}
+
+func TestInvariantsDetectProblems(t *testing.T) {
+	doc, err := getDoc("<div><p>a</p><p>b</p></div>")
+	assert.NoError(t, err)
+
+	div := doc.First()
+	p := div.First()
+
+	// stale parent
+	p._parent = nil
+	assert.Error(t, doc.checkInvariants())
+	p._parent = div
+
+	// wrapped child
+	p._wrappingElements = doc
+	assert.Error(t, doc.checkInvariants())
+	p._wrappingElements = nil
+
+	// node in two places
+	div._children = append(div._children, p)
+	assert.Error(t, doc.checkInvariants())
+	div._children = div._children[:2]
+
+	// nil child
+	div._children = append(div._children, nil)
+	assert.Error(t, doc.checkInvariants())
+	div._children = div._children[:2]
+
+	// top-level node not wrapped
+	div._wrappingElements = nil
+	assert.Error(t, doc.checkInvariants())
+	div._wrappingElements = doc
+
+	// parent does not know the node
+	orphan := NewText("x")
+	orphan._parent = div
+	assert.Error(t, orphan.checkInvariants())
+
+	assert.NoError(t, doc.checkInvariants())
+}
@@ -0,0 +1,275 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+//
+// A brace-wrapped span, such as `{a > b}`, within a raw tag. The
+// offsets are relative to the start of the raw tag.
+//
+type braceSpan struct {
+	start int // offset of the open brace
+	end   int // offset right after the close brace
+}
+
+//
+// Scans the raw markup of a tag as per JSX rules, where attribute
+// values and spread attributes may be wrapped in balanced braces
+// that can contain `>`, quotes and nested braces. Bytes are fed one
+// at a time, so that the end of a tag can be found while reading.
+//
+type jsxScanner struct {
+	offset  int         // the number of bytes fed so far
+	depth   int         // the number of braces open
+	quote   byte        // the quote open, if any
+	escaped bool        // whether the next byte is escaped
+	equals  bool        // whether the last byte outside braces was `=`
+	start   int         // offset of the outermost open brace
+	spans   []braceSpan // the outermost brace spans found so far
+}
+
+//
+// Feed the next byte of the tag to the scanner. Returns `true` if
+// the byte is the `>` that ends the tag.
+//
+func (scanner *jsxScanner) feed(c byte) bool {
+	offset := scanner.offset
+	scanner.offset++
+
+	switch {
+	case scanner.escaped:
+		scanner.escaped = false
+
+	case scanner.quote != 0:
+		if c == '\\' && scanner.depth > 0 {
+			scanner.escaped = true
+		} else if c == scanner.quote {
+			scanner.quote = 0
+		}
+
+	case scanner.depth > 0:
+		switch c {
+		case '{':
+			scanner.depth++
+
+		case '}':
+			scanner.depth--
+			if scanner.depth == 0 {
+				scanner.spans = append(scanner.spans, braceSpan{scanner.start, offset + 1})
+			}
+
+		case '"', '\'', '`':
+			scanner.quote = c
+		}
+
+	case c == '{':
+		scanner.depth = 1
+		scanner.start = offset
+
+	case c == '>':
+		return true
+
+	case (c == '"' || c == '\'') && scanner.equals:
+		scanner.quote = c
+	}
+
+	if scanner.depth == 0 && !isWhitespace(c) {
+		scanner.equals = c == '='
+	}
+
+	return false
+}
+
+//
+// Read the current start tag as per JSX rules. If the tokenizer
+// ended the tag too early, at a `>` within braces, the rest of the
+// tag is read from the input and the tokenizer is restarted after
+// it. If a brace is never closed, the tag is kept as read by the
+// tokenizer. Returns a tokenizer positioned at the tag, with all brace
+// spans masked, along with the spans and if the tag is self-closing.
+//
+func (parser *parser) readJsxTag(selfClosing bool) (*html.Tokenizer, []braceSpan, bool, error) {
+	tokenizer := parser.tokenizer
+	scanner := &jsxScanner{}
+	complete := false
+	for index := 0; index < len(parser.masked) && !complete; index++ {
+		complete = scanner.feed(parser.masked[index])
+	}
+
+	// the tag has no braces, or the tokenizer read it differently
+	if scanner.offset < len(parser.masked) || (complete && len(scanner.spans) == 0) {
+		return tokenizer, nil, selfClosing, nil
+	}
+
+	if !complete {
+		reader, source := parser.takeOver()
+		rest := strings.Builder{}
+		for !complete {
+			c, err := reader.ReadByte()
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				return nil, nil, false, err
+			}
+
+			rest.WriteByte(c)
+			complete = scanner.feed(c)
+		}
+
+		// a brace is never closed, so we keep the tag as read by the
+		// tokenizer and parse the rest of the input as usual
+		if !complete {
+			parser.report(UnterminatedExpression, SeverityWarning, "Brace in tag '"+parser.raw+"' is never closed", parser.position.advance(parser.raw[:scanner.start]))
+			parser.resumeWith(rest.String(), reader, source)
+			return tokenizer, nil, selfClosing, nil
+		}
+
+		parser.resume(reader, source)
+		parser.masked += rest.String()
+		parser.raw = parser.restore(parser.masked)
+	}
+
+	masked := maskBraces(parser.masked, scanner.spans)
+	masked = separateBraces(masked, braceSeparators(masked, scanner.spans))
+	tokenizer = html.NewTokenizer(strings.NewReader(masked))
+	token := tokenizer.Next()
+	return tokenizer, scanner.spans, token == html.SelfClosingTagToken, nil
+}
+
+//
+// Replace every byte of the given brace spans in the given raw tag
+// with `expressionMask`.
+//
+func maskBraces(raw string, spans []braceSpan) string {
+	if len(spans) == 0 {
+		return raw
+	}
+
+	masked := []byte(raw)
+	for _, span := range spans {
+		for index := span.start; index < span.end; index++ {
+			masked[index] = expressionMask
+		}
+	}
+
+	return string(masked)
+}
+
+//
+// Return the offsets in the given masked tag, right after a brace
+// span, that are directly followed by another byte of the tag, such
+// as the `/` in `a={b}/>`. A space is inserted at these offsets by
+// `separateBraces()`.
+//
+func braceSeparators(masked string, spans []braceSpan) []int {
+	separators := make([]int, 0)
+	for _, span := range spans {
+		if span.end < len(masked) && !isWhitespace(masked[span.end]) && masked[span.end] != '>' {
+			separators = append(separators, span.end)
+		}
+	}
+
+	return separators
+}
+
+//
+// Insert a space at each of the given offsets of the given masked
+// tag. The tokenizer then ends unquoted values at the close brace,
+// just as JSX does.
+//
+func separateBraces(masked string, separators []int) string {
+	if len(separators) == 0 {
+		return masked
+	}
+
+	result := strings.Builder{}
+	last := 0
+	for _, separator := range separators {
+		result.WriteString(masked[last:separator])
+		result.WriteByte(' ')
+		last = separator
+	}
+
+	result.WriteString(masked[last:])
+	return result.String()
+}
+
+//
+// Map the offsets of the given tag, scanned from a tag separated
+// at the given offsets, back to the offsets of the tag before it
+// was separated.
+//
+func unseparateTag(tag *rawTag, separators []int) {
+	if len(separators) == 0 {
+		return
+	}
+
+	unseparate := func(offset int) int {
+		// the space inserted at `separators[k]` is at `separators[k] + k`
+		count := 0
+		for count < len(separators) && separators[count]+count < offset {
+			count++
+		}
+
+		return offset - count
+	}
+
+	tag.nameStart, tag.nameEnd = unseparate(tag.nameStart), unseparate(tag.nameEnd)
+	for _, attr := range tag.attributes {
+		attr.nameStart, attr.nameEnd = unseparate(attr.nameStart), unseparate(attr.nameEnd)
+		attr.valueStart, attr.valueEnd = unseparate(attr.valueStart), unseparate(attr.valueEnd)
+	}
+}
+
+//
+// Mark the attributes of the given raw tag whose value, or the
+// whole attribute, is one of the given brace spans. The value of
+// such attributes excludes the braces, and for spread attributes
+// the leading dots.
+//
+func markBracedAttributes(tag *rawTag, raw string, spans []braceSpan) {
+	for _, span := range spans {
+		for _, attr := range tag.attributes {
+			switch {
+			case attr.hasValue && attr.valueStart == span.start && attr.valueEnd == span.end:
+				attr.braced = true
+				attr.valueStart++
+				attr.valueEnd--
+
+			case attr.nameStart == span.start && attr.nameEnd == span.end:
+				inner := raw[span.start+1 : span.end-1]
+				code := strings.TrimLeft(inner, whitespace)
+				if !strings.HasPrefix(code, "...") {
+					continue
+				}
+
+				attr.spread = true
+				attr.hasValue = true
+				attr.valueStart = span.start + 1 + len(inner) - len(code) + len("...")
+				attr.valueEnd = span.start + 1 + len(strings.TrimRight(inner, whitespace))
+
+			default:
+				continue
+			}
+
+			attr.value = raw[attr.valueStart:attr.valueEnd]
+		}
+	}
+}
@@ -0,0 +1,205 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// Parse the given markup with JSX attributes enabled.
+//
+func getJsx(t *testing.T, html string) *HtmlElements {
+	options := getDefaultOptions()
+	options.JsxAttributes = true
+
+	doc, err := ParseWithOptions(strings.NewReader(html), options)
+	assert.NoError(t, err)
+	return doc
+}
+
+func TestJsxAttributes(t *testing.T) {
+	html := `<div><Comp value={a > b ? "x" : "y"} {...props} style={{ color: '}' }} title="a>b" disabled /><p>after</p></div>`
+	doc := getJsx(t, html)
+
+	div := doc.First()
+	assert.Equal(t, 2, div.NumChildren())
+
+	comp := div.First()
+	assert.Equal(t, "Comp", comp.RawNodeName())
+	assert.True(t, comp.IsSelfClosing)
+	assert.False(t, comp.HasChildren())
+	assert.Equal(t, 5, comp.NumAttributes())
+
+	value := comp.Attributes[0]
+	assert.Equal(t, "value", value.Name)
+	assert.Equal(t, `a > b ? "x" : "y"`, value.Value)
+	assert.Equal(t, BracedValue, value.ValueStyle)
+	assert.Equal(t, value.Value, value.RawValue())
+	assert.Equal(t, 18, value.ValueRange().Start.Offset)
+
+	spread := comp.Attributes[1]
+	assert.Equal(t, "", spread.Name)
+	assert.Equal(t, "props", spread.Value)
+	assert.Equal(t, SpreadValue, spread.ValueStyle)
+
+	style := comp.GetAttribute("style")
+	assert.Equal(t, "{ color: '}' }", style.Value)
+	assert.Equal(t, BracedValue, style.ValueStyle)
+
+	assert.Equal(t, "a>b", comp.GetAttribute("title").Value)
+	assert.Equal(t, QuotedValue, comp.GetAttribute("title").ValueStyle)
+	assert.Equal(t, NoValue, comp.GetAttribute("disabled").ValueStyle)
+
+	// the siblings are read after the tag
+	assert.Equal(t, "after", div.Last().Text())
+
+	builder := strings.Builder{}
+	assert.NoError(t, doc.Render(&builder, RenderOptions{PreserveSource: true}))
+	assert.Equal(t, html, builder.String())
+
+	// modified tags are written in the same style
+	value.Value = "c"
+	spread.Value = "rest"
+	assert.Equal(t, `<Comp value={c} {...rest} style={{ color: '}' }} title="a>b" disabled />`, comp.String())
+}
+
+func TestJsxSpreadAttributesAreNotMerged(t *testing.T) {
+	options := getDefaultOptions()
+	options.JsxAttributes = true
+	options.AllowMultipleAttributesWithSameName = false
+
+	result, err := ParseWithDiagnostics(strings.NewReader("<a {...x} { ...y } {z} b={1}>"), options)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(result.Diagnostics.WithCode(DuplicateAttribute)))
+
+	a := result.Elements.First()
+	assert.Equal(t, 4, a.NumAttributes())
+	assert.Equal(t, "x", a.Attributes[0].Value)
+	assert.Equal(t, "y", a.Attributes[1].Value)
+
+	// braces without dots are just a name
+	assert.Equal(t, "{z}", a.Attributes[2].Name)
+	assert.Equal(t, NoValue, a.Attributes[2].ValueStyle)
+	assert.Equal(t, "1", a.GetAttribute("b").Value)
+}
+
+func TestJsxDisabled(t *testing.T) {
+	doc, err := getDoc(`<p value={a > b}>x</p>`)
+	assert.NoError(t, err)
+
+	p := doc.First()
+	assert.Equal(t, "{a", p.GetAttribute("value").Value)
+	assert.Equal(t, UnquotedValue, p.GetAttribute("value").ValueStyle)
+}
+
+func TestJsxUnclosedBrace(t *testing.T) {
+	options := getDefaultOptions()
+	options.JsxAttributes = true
+
+	// the tag is read as if braces were not special
+	result, err := ParseWithDiagnostics(strings.NewReader("<div a={1>x</div><p>y</p>"), options)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Elements.Length())
+
+	div := result.Elements.First()
+	assert.Equal(t, "{1", div.GetAttribute("a").Value)
+	assert.Equal(t, UnquotedValue, div.GetAttribute("a").ValueStyle)
+	assert.Equal(t, "x", div.Text())
+	assert.Equal(t, "y", result.Elements.Last().Text())
+
+	diagnostics := result.Diagnostics.WithCode(UnterminatedExpression)
+	assert.Equal(t, 1, len(diagnostics))
+	assert.Equal(t, 7, diagnostics[0].Position.Offset)
+
+	// the input ends within the brace
+	doc := getJsx(t, "<p a={x > y")
+	assert.Equal(t, 1, doc.Length())
+	p := doc.First()
+	assert.Equal(t, "p", p.NodeName())
+	assert.Equal(t, "{x", p.GetAttribute("a").Value)
+	assert.Equal(t, " y", p.Text())
+}
+
+func TestJsxSelfClosingTags(t *testing.T) {
+	doc := getJsx(t, "<div><X a={x}/><X {...p}/><X b={y} c={z}/>after</div>")
+
+	div := doc.First()
+	assert.Equal(t, 4, div.NumChildren())
+
+	x := div.First()
+	assert.True(t, x.IsSelfClosing)
+	assert.Equal(t, 1, x.NumAttributes())
+	assert.Equal(t, "x", x.GetAttribute("a").Value)
+	assert.Equal(t, BracedValue, x.GetAttribute("a").ValueStyle)
+	assert.Equal(t, "<X a={x} />", x.String())
+
+	spread := div.Get(1)
+	assert.True(t, spread.IsSelfClosing)
+	assert.Equal(t, 1, spread.NumAttributes())
+	assert.Equal(t, "p", spread.Attributes[0].Value)
+	assert.Equal(t, SpreadValue, spread.Attributes[0].ValueStyle)
+
+	last := div.Get(2)
+	assert.True(t, last.IsSelfClosing)
+	assert.Equal(t, "y", last.GetAttribute("b").Value)
+	assert.Equal(t, "z", last.GetAttribute("c").Value)
+	assert.Equal(t, "after", div.Last().Text())
+}
+
+func TestJsxWithExpressions(t *testing.T) {
+	options := getDefaultOptions()
+	options.JsxAttributes = true
+	options.ExpressionDelimiters = []ExpressionDelimiters{{Open: "{{", Close: "}}"}}
+
+	doc, err := ParseWithOptions(strings.NewReader(`<p a={x > "{{ y }}"} title="{{ z }}">{{ w }}</p>`), options)
+	assert.NoError(t, err)
+
+	p := doc.First()
+	assert.Equal(t, `x > "{{ y }}"`, p.GetAttribute("a").Value)
+	assert.Equal(t, "{{ z }}", p.GetAttribute("title").Value)
+	assert.Equal(t, ExpressionNode, p.First().NodeType)
+}
+
+func TestJsxBracedValueFollowedByAttribute(t *testing.T) {
+	html := "<div><C a={x}b c={y}/><C a={x}b={y}/></div>"
+	doc := getJsx(t, html)
+
+	// followed by an attribute without a value
+	first := doc.First().First()
+	assert.True(t, first.IsSelfClosing)
+	assert.Equal(t, 3, first.NumAttributes())
+	assert.Equal(t, "x", first.GetAttribute("a").Value)
+	assert.Equal(t, BracedValue, first.GetAttribute("a").ValueStyle)
+	assert.Equal(t, NoValue, first.GetAttribute("b").ValueStyle)
+	assert.Equal(t, "y", first.GetAttribute("c").Value)
+	assert.Equal(t, BracedValue, first.GetAttribute("c").ValueStyle)
+	assert.Equal(t, 18, first.GetAttribute("c").ValueRange().Start.Offset)
+
+	// followed by an attribute with a value
+	last := doc.First().Last()
+	assert.True(t, last.IsSelfClosing)
+	assert.Equal(t, 2, last.NumAttributes())
+	assert.Equal(t, "x", last.GetAttribute("a").Value)
+	b := last.GetAttribute("b")
+	assert.Equal(t, "y", b.Value)
+	assert.Equal(t, "y", b.RawValue())
+	assert.Equal(t, BracedValue, b.ValueStyle)
+	assert.Equal(t, 33, b.ValueRange().Start.Offset)
+
+	builder := strings.Builder{}
+	assert.NoError(t, doc.Render(&builder, RenderOptions{PreserveSource: true}))
+	assert.Equal(t, html, builder.String())
+}
@@ -17,18 +17,219 @@ import (
 	"strings"
 )
 
+//
+// Defines how the parser recovers when it encounters an end
+// tag that does not match the element at the top of the stack
+// of open elements.
+//
+type EndTagRecovery uint32
+
+// Enumeration
+const (
+	// Search down the stack of open elements for an element with
+	// the same name and implicitly close every element above it.
+	// If no open element matches, the end tag is ignored.
+	CloseMatchingElement EndTagRecovery = iota
+
+	// Ignore any end tag that does not match the element at the
+	// top of the stack.
+	IgnoreMismatchedEndTag
+
+	// Fail the parse with an error when an end tag does not match
+	// the element at the top of the stack.
+	FailOnMismatchedEndTag
+)
+
+//
+// Defines how the values of attributes with the same name on a
+// single tag are merged when multiple attributes with the same
+// name are not allowed.
+//
+type DuplicateAttributePolicy uint32
+
+// Enumeration
+const (
+	// Keep the value of the first attribute and drop the others.
+	FirstAttributeWins DuplicateAttributePolicy = iota
+
+	// Keep the value of the last attribute and drop the others.
+	LastAttributeWins
+
+	// Concatenate the values of `class` (separated by a space) and
+	// `style` (separated by a semicolon) attributes. For all other
+	// attributes the value of the first attribute is kept.
+	ConcatenateAttributeValues
+)
+
+//
+// Defines which spelling of text and attribute values is returned
+// by `HtmlNode.Data` and `HtmlAttribute.Value`. Both spellings are
+// always kept, and can be read using `RawData()`/`DecodedData()` and
+// `RawValue()`/`DecodedValue()` respectively.
+//
+type ValueMode uint32
+
+// Enumeration
+const (
+	// Character references, such as `&amp;` or `&nbsp;`, are decoded
+	// into the characters they represent.
+	DecodedValues ValueMode = iota
+
+	// Values are kept exactly as they were spelled in source.
+	RawValues
+)
+
+//
+// The elements that can never have any content, and thus are
+// closed immediately when their start tag is encountered.
+//
+var defaultVoidElements = []string{
+	"area", "base", "br", "col", "embed", "hr", "img", "input",
+	"link", "meta", "param", "source", "track", "wbr",
+}
+
+//
+// Lookup set of `defaultVoidElements`.
+//
+var defaultVoidElementSet = toNameSet(defaultVoidElements)
+
+//
+// Return a copy of the elements that are void by default. Use this
+// list to add your own void elements to `ParseOptions`.
+//
+func DefaultVoidElements() []string {
+	return append([]string(nil), defaultVoidElements...)
+}
+
+//
+// The elements whose content is never parsed, but kept as a single
+// text node, as is done by browsers.
+//
+var defaultRawTextElements = []string{
+	"script", "style", "textarea", "xmp", "iframe", "noembed", "noframes", "noscript", "plaintext",
+}
+
+//
+// The elements whose content is parsed even though browsers treat it
+// as raw text. This allows a `title` to contain custom tags.
+//
+var defaultParseInsideElements = []string{
+	"title",
+}
+
+//
+// Return a copy of the elements that are raw text by default. Use
+// this list to add your own raw text elements, such as `custom:Code`,
+// to `ParseOptions`.
+//
+func DefaultRawTextElements() []string {
+	return append([]string(nil), defaultRawTextElements...)
+}
+
+//
+// Return a copy of the elements whose content is parsed by default,
+// even though browsers treat it as raw text.
+//
+func DefaultParseInsideElements() []string {
+	return append([]string(nil), defaultParseInsideElements...)
+}
+
 type ParseOptions struct {
-	CaseSensitiveAttributes             bool
-	AllowMultipleAttributesWithSameName bool
+	CaseSensitiveAttributes             bool                     // match attribute names case-sensitively, and keep their spelling
+	AllowMultipleAttributesWithSameName bool                     // keep all attributes with the same name on a tag
+	DuplicateAttributePolicy            DuplicateAttributePolicy // how to merge attributes with the same name if not allowed
+	EndTagRecovery                      EndTagRecovery           // how to handle stray/mismatched end tags
+	VoidElements                        []string                 // elements that have no content, `DefaultVoidElements()` if `nil`
+	RawTextElements                     []string                 // elements whose content is kept as a single text node, `DefaultRawTextElements()` if `nil`
+	ParseInsideElements                 []string                 // elements whose content is always parsed, `DefaultParseInsideElements()` if `nil`
+	PreserveWhitespace                  bool                     // keep text nodes that only contain whitespace
+	FlatComments                        bool                     // add all comments at the top level, as older versions did
+	ValueMode                           ValueMode                // whether `Data` and `Value` hold decoded or raw text
+	ExpressionDelimiters                []ExpressionDelimiters   // delimiters of template expressions, none are recognized if empty
+	JsxAttributes                       bool                     // read brace-wrapped values and spread attributes, such as `value={a > b} {...props}`
+	Namespaces                          map[string]string        // namespace URIs of prefixes that are not declared with `xmlns:prefix` in the markup
 }
 
 func getDefaultOptions() *ParseOptions {
 	return &ParseOptions{
 		CaseSensitiveAttributes:             false,
 		AllowMultipleAttributesWithSameName: false,
+		DuplicateAttributePolicy:            FirstAttributeWins,
+		EndTagRecovery:                      CloseMatchingElement,
+		VoidElements:                        nil,
+		RawTextElements:                     nil,
+		ParseInsideElements:                 nil,
+		PreserveWhitespace:                  false,
+		FlatComments:                        false,
+		ValueMode:                           DecodedValues,
+		ExpressionDelimiters:                nil,
+		JsxAttributes:                       false,
+		Namespaces:                          nil,
 	}
 }
 
+//
+// Return the names of void elements as a lookup set with
+// lower-cased names.
+//
+func (options *ParseOptions) voidElements() map[string]bool {
+	if options.VoidElements == nil {
+		return defaultVoidElementSet
+	}
+
+	return toNameSet(options.VoidElements)
+}
+
+//
+// Check if the content of the element with the given lower-cased
+// name is kept as a single text node. Elements that are to be
+// parsed inside take precedence over raw text elements.
+//
+func (options *ParseOptions) isRawTextElement(name string) bool {
+	parseInside := options.ParseInsideElements
+	if parseInside == nil {
+		parseInside = defaultParseInsideElements
+	}
+
+	if containsName(parseInside, name) {
+		return false
+	}
+
+	rawText := options.RawTextElements
+	if rawText == nil {
+		rawText = defaultRawTextElements
+	}
+
+	return containsName(rawText, name)
+}
+
+//
+// Convert the given tag names into a lookup set with lower-cased
+// names.
+//
+func toNameSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+
+	return set
+}
+
+//
+// Check if the given lower-cased tag name is one of the given
+// tag names.
+//
+func containsName(names []string, name string) bool {
+	for _, candidate := range names {
+		if strings.EqualFold(strings.TrimSpace(candidate), name) {
+			return true
+		}
+	}
+
+	return false
+}
+
 //
 // A loose HTML parser that just returns the tags and their
 // attributes in the order they appear. It makes no assumption
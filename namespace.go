@@ -0,0 +1,301 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"sort"
+	"strings"
+)
+
+//
+// The namespace URI bound to the `xml` prefix.
+//
+const XmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+//
+// The namespace URI bound to the `xmlns` prefix, and of the
+// `xmlns` attribute itself.
+//
+const XmlnsNamespace = "http://www.w3.org/2000/xmlns/"
+
+//
+// Matches any namespace or local name in the namespace-aware
+// lookups, such as `GetElementsByNameNS()`.
+//
+const AnyName = "*"
+
+//----- Nodes
+
+//
+// Return the prefix of the name of this element, such as `custom`
+// for `custom:PageBody`, as it was spelled in source. Returns an
+// empty string if the name has no prefix, or for nodes that are
+// not elements.
+//
+func (node *HtmlNode) Prefix() string {
+	if node.NodeType != ElementNode {
+		return ""
+	}
+
+	prefix, _ := splitQualifiedName(node.RawNodeName())
+	return prefix
+}
+
+//
+// Return the name of this element without its prefix, such as
+// `PageBody` for `custom:PageBody`, as it was spelled in source.
+// Returns an empty string for nodes that are not elements.
+//
+func (node *HtmlNode) LocalName() string {
+	if node.NodeType != ElementNode {
+		return ""
+	}
+
+	_, localName := splitQualifiedName(node.RawNodeName())
+	return localName
+}
+
+//
+// Return the namespace URI of this element. The prefix of the name
+// is resolved using the `xmlns:prefix` declarations on this element
+// and its ancestors, and elements without a prefix use the nearest
+// `xmlns` declaration. Prefixes that are not declared in markup are
+// looked up in the `Namespaces` of the parse options. Returns an
+// empty string if the namespace is not known.
+//
+func (node *HtmlNode) NamespaceURI() string {
+	if node.NodeType != ElementNode {
+		return ""
+	}
+
+	return node.LookupNamespaceURI(node.Prefix())
+}
+
+//
+// Return the namespace URI bound to the given prefix as seen from
+// this node, or the default namespace for an empty prefix. See
+// `NamespaceURI()` for how the prefix is resolved.
+//
+func (node *HtmlNode) LookupNamespaceURI(prefix string) string {
+	return lookupNamespaceURI(node, node.options(), prefix)
+}
+
+//
+// Find all elements within this node (including this one) that
+// have the given namespace URI and local name. Either can be
+// `AnyName` to match all. Local names are matched ignoring case.
+//
+// Returns an instance of `HtmlElements` which contains all the
+// selected nodes. If no match is found, an empty list is returned.
+// This method never returns a `nil`.
+//
+func (node *HtmlNode) GetElementsByNameNS(namespaceURI string, localName string) *HtmlElements {
+	elements := NewHtmlElements()
+	node.getElementsByNameNSInternal(namespaceURI, strings.TrimSpace(localName), elements)
+	return elements
+}
+
+//
+// Find all elements within this list of elements that have the
+// given namespace URI and local name. See `GetElementsByNameNS()`
+// on `HtmlNode` for how names are matched.
+//
+func (elements *HtmlElements) GetElementsByNameNS(namespaceURI string, localName string) *HtmlElements {
+	result := NewHtmlElements()
+	localName = strings.TrimSpace(localName)
+	for _, child := range elements.list().nodes {
+		child.getElementsByNameNSInternal(namespaceURI, localName, result)
+	}
+
+	return result
+}
+
+//
+// Find and return the first attribute of this node that has the
+// given namespace URI and local name. Either can be `AnyName` to
+// match all. Local names are matched as per the options this node
+// was parsed with.
+//
+// Returns an `HtmlAttribute` if found, `nil` otherwise
+//
+func (node *HtmlNode) GetAttributeNS(namespaceURI string, localName string) *HtmlAttribute {
+	if !node.ContainsAttributes() {
+		return nil
+	}
+
+	for _, attr := range node.Attributes {
+		if localName != AnyName && !node.attributeNameMatches(attr.LocalName(), localName) {
+			continue
+		}
+
+		if namespaceURI == AnyName || attr.NamespaceURI() == namespaceURI {
+			return attr
+		}
+	}
+
+	return nil
+}
+
+//
+// Check if the node has an attribute with the given namespace URI
+// and local name.
+//
+func (node *HtmlNode) HasAttributeNS(namespaceURI string, localName string) bool {
+	return node.GetAttributeNS(namespaceURI, localName) != nil
+}
+
+//----- Attributes
+
+//
+// Return the prefix of the name of this attribute, such as `custom`
+// for `custom:title`, as it was spelled in source. Returns an empty
+// string if the name has no prefix.
+//
+func (attr *HtmlAttribute) Prefix() string {
+	prefix, _ := splitQualifiedName(attr.RawName())
+	return prefix
+}
+
+//
+// Return the name of this attribute without its prefix, such as
+// `title` for `custom:title`, as it was spelled in source.
+//
+func (attr *HtmlAttribute) LocalName() string {
+	_, localName := splitQualifiedName(attr.RawName())
+	return localName
+}
+
+//
+// Return the namespace URI of this attribute. The prefix of the
+// name is resolved from the node the attribute belongs to, as for
+// `HtmlNode.NamespaceURI()`. Attributes without a prefix are in no
+// namespace, except for `xmlns` itself. Returns an empty string if
+// the namespace is not known.
+//
+func (attr *HtmlAttribute) NamespaceURI() string {
+	prefix := attr.Prefix()
+	if prefix == "" {
+		if attr.ValueStyle != SpreadValue && strings.EqualFold(attr.Name, "xmlns") {
+			return XmlnsNamespace
+		}
+
+		return ""
+	}
+
+	if attr._owner == nil {
+		return lookupNamespaceURI(nil, attr.options(), prefix)
+	}
+
+	return attr._owner.LookupNamespaceURI(prefix)
+}
+
+//----- Internal methods
+
+//
+// Internal method to help with collection of nodes that match the
+// namespace URI and local name.
+//
+func (node *HtmlNode) getElementsByNameNSInternal(namespaceURI string, localName string, elements *HtmlElements) {
+	// collect without attaching the node to the result
+	if node.matchesNameNS(namespaceURI, localName) {
+		elements.collect(node)
+	}
+
+	for _, child := range node._children {
+		child.getElementsByNameNSInternal(namespaceURI, localName, elements)
+	}
+}
+
+//
+// Check if this node is an element with the given namespace URI
+// and local name.
+//
+func (node *HtmlNode) matchesNameNS(namespaceURI string, localName string) bool {
+	if node.NodeType != ElementNode {
+		return false
+	}
+
+	if localName != AnyName && !strings.EqualFold(node.LocalName(), localName) {
+		return false
+	}
+
+	return namespaceURI == AnyName || node.NamespaceURI() == namespaceURI
+}
+
+//
+// Split the given name at the first colon into a prefix and a
+// local name. Names without a colon, or with nothing on either
+// side of it, have no prefix.
+//
+func splitQualifiedName(name string) (string, string) {
+	index := strings.IndexByte(name, ':')
+	if index <= 0 || index == len(name)-1 {
+		return "", name
+	}
+
+	return name[:index], name[index+1:]
+}
+
+//
+// Resolve the given prefix, or the default namespace for an empty
+// prefix, using the declarations on the given node and its
+// ancestors, then the namespaces of the given options, and lastly
+// the reserved `xml` and `xmlns` prefixes. Prefixes are matched
+// ignoring case, as element and attribute names are lower-cased by
+// default, though an exact match of the options is preferred.
+//
+func lookupNamespaceURI(node *HtmlNode, options *ParseOptions, prefix string) string {
+	name := "xmlns"
+	if prefix != "" {
+		name = "xmlns:" + prefix
+	}
+
+	for current := node; current != nil; current = current._parent {
+		if current.NodeType != ElementNode {
+			continue
+		}
+
+		for _, attr := range current.Attributes {
+			if attr.ValueStyle != SpreadValue && strings.EqualFold(attr.Name, name) {
+				return attr.DecodedValue()
+			}
+		}
+	}
+
+	if uri, found := options.Namespaces[prefix]; found {
+		return uri
+	}
+
+	// keys that differ only by case are tried in sorted order,
+	// so that the same one is always found
+	keys := make([]string, 0)
+	for key := range options.Namespaces {
+		if strings.EqualFold(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		return options.Namespaces[keys[0]]
+	}
+
+	switch strings.ToLower(prefix) {
+	case "xml":
+		return XmlNamespace
+
+	case "xmlns":
+		return XmlnsNamespace
+	}
+
+	return ""
+}
@@ -0,0 +1,169 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const uiNamespace = "urn:ui"
+const formsNamespace = "urn:forms"
+
+func TestNamespaceNames(t *testing.T) {
+	doc, err := getDoc(`<div xmlns:custom="urn:ui"><custom:PageBody custom:title="Home" id="x"></custom:PageBody><p></p></div>`)
+	assert.NoError(t, err)
+
+	div := doc.First()
+	assert.Equal(t, "", div.Prefix())
+	assert.Equal(t, "div", div.LocalName())
+	assert.Equal(t, "", div.NamespaceURI())
+
+	body := div.First()
+	assert.Equal(t, "custom", body.Prefix())
+	assert.Equal(t, "PageBody", body.LocalName())
+	assert.Equal(t, uiNamespace, body.NamespaceURI())
+
+	title := body.GetAttribute("custom:title")
+	assert.Equal(t, "custom", title.Prefix())
+	assert.Equal(t, "title", title.LocalName())
+	assert.Equal(t, uiNamespace, title.NamespaceURI())
+
+	// attributes without a prefix have no namespace
+	id := body.GetAttribute("id")
+	assert.Equal(t, "", id.Prefix())
+	assert.Equal(t, "id", id.LocalName())
+	assert.Equal(t, "", id.NamespaceURI())
+
+	declaration := div.GetAttribute("xmlns:custom")
+	assert.Equal(t, "xmlns", declaration.Prefix())
+	assert.Equal(t, XmlnsNamespace, declaration.NamespaceURI())
+
+	// text nodes have no names
+	text := NewText("x")
+	assert.Equal(t, "", text.Prefix())
+	assert.Equal(t, "", text.LocalName())
+	assert.Equal(t, "", text.NamespaceURI())
+}
+
+func TestNamespaceResolution(t *testing.T) {
+	html := `<div xmlns="urn:default" xmlns:ui="urn:ui"><section xmlns:ui="urn:forms"><ui:Input /></section><ui:Button /><span></span></div>`
+	doc, err := getDoc(html)
+	assert.NoError(t, err)
+
+	div := doc.First()
+	assert.Equal(t, "urn:default", div.NamespaceURI())
+	assert.Equal(t, "urn:default", div.GetElementsByName("span").First().NamespaceURI())
+
+	// the nearest declaration wins
+	input := div.GetElementsByName("ui:input").First()
+	assert.Equal(t, formsNamespace, input.NamespaceURI())
+	assert.Equal(t, uiNamespace, div.GetElementsByName("ui:button").First().NamespaceURI())
+
+	// reserved and unknown prefixes
+	assert.Equal(t, XmlNamespace, input.LookupNamespaceURI("xml"))
+	assert.Equal(t, "", input.LookupNamespaceURI("other"))
+
+	// detached nodes only see their own declarations
+	input.RemoveMe()
+	assert.Equal(t, "", input.NamespaceURI())
+}
+
+func TestNamespacesOption(t *testing.T) {
+	options := getDefaultOptions()
+	options.Namespaces = map[string]string{
+		"ui":    uiNamespace,
+		"Forms": formsNamespace,
+	}
+
+	doc, err := ParseWithOptions(strings.NewReader(`<ui:Card forms:name="x"><forms:Field xmlns:ui="urn:other"><ui:Label /></forms:Field></ui:Card>`), options)
+	assert.NoError(t, err)
+
+	card := doc.First()
+	assert.Equal(t, uiNamespace, card.NamespaceURI())
+	assert.Equal(t, formsNamespace, card.Attributes[0].NamespaceURI())
+
+	// declarations in markup take precedence
+	label := card.GetElementsByName("ui:label").First()
+	assert.Equal(t, "urn:other", label.NamespaceURI())
+}
+
+func TestNamespacesOptionDifferingByCase(t *testing.T) {
+	options := getDefaultOptions()
+	options.Namespaces = map[string]string{
+		"UI": "urn:upper",
+		"Ui": "urn:mixed",
+		"ui": uiNamespace,
+	}
+
+	// an exact match wins, and otherwise the first key in order
+	for index := 0; index < 20; index++ {
+		assert.Equal(t, uiNamespace, lookupNamespaceURI(nil, options, "ui"))
+		assert.Equal(t, "urn:upper", lookupNamespaceURI(nil, options, "uI"))
+	}
+}
+
+func TestGetElementsByNameNS(t *testing.T) {
+	html := `<div xmlns:a="urn:ui" xmlns:b="urn:ui" xmlns:c="urn:forms"><a:Button /><b:button /><c:Button /><button></button></div>`
+	doc, err := getDoc(html)
+	assert.NoError(t, err)
+
+	// different prefixes can map to the same namespace
+	buttons := doc.GetElementsByNameNS(uiNamespace, "Button")
+	assert.Equal(t, 2, buttons.Length())
+	assert.Equal(t, "a:Button", buttons.First().RawNodeName())
+	assert.Equal(t, "b:button", buttons.Last().RawNodeName())
+
+	assert.Equal(t, 1, doc.GetElementsByNameNS(formsNamespace, "button").Length())
+	assert.Equal(t, 1, doc.GetElementsByNameNS("", "button").Length())
+	assert.Equal(t, 4, doc.GetElementsByNameNS(AnyName, "button").Length())
+	assert.Equal(t, 2, doc.First().GetElementsByNameNS(uiNamespace, AnyName).Length())
+	assert.Equal(t, 0, doc.GetElementsByNameNS("urn:none", AnyName).Length())
+
+	// the result is detached from the tree
+	assert.Equal(t, 4, doc.First().NumChildren())
+}
+
+func TestGetAttributeNS(t *testing.T) {
+	doc, err := getDoc(`<div xmlns:a="urn:ui" xmlns:b="urn:forms"><p b:title="forms" a:title="ui" title="plain"></p></div>`)
+	assert.NoError(t, err)
+
+	p := doc.First().First()
+	assert.Equal(t, "ui", p.GetAttributeNS(uiNamespace, "title").Value)
+	assert.Equal(t, "forms", p.GetAttributeNS(formsNamespace, "TITLE").Value)
+	assert.Equal(t, "plain", p.GetAttributeNS("", "title").Value)
+	assert.Equal(t, "forms", p.GetAttributeNS(AnyName, "title").Value)
+	assert.True(t, p.HasAttributeNS(uiNamespace, AnyName))
+	assert.False(t, p.HasAttributeNS("urn:none", "title"))
+}
+
+func TestNamespaceOfModifiedTree(t *testing.T) {
+	node := El("ui:Card").Attr("xmlns:ui", uiNamespace).Children(
+		El("ui:Label").Attr("ui:for", "x"),
+	).Build()
+
+	label := node.First()
+	assert.Equal(t, uiNamespace, label.NamespaceURI())
+	assert.Equal(t, uiNamespace, label.GetAttribute("ui:for").NamespaceURI())
+
+	// clones resolve against their own ancestors
+	clone := node.Clone(true)
+	clone.SetAttribute("xmlns:ui", formsNamespace)
+	assert.Equal(t, formsNamespace, clone.First().GetAttribute("ui:for").NamespaceURI())
+	assert.Equal(t, uiNamespace, label.GetAttribute("ui:for").NamespaceURI())
+
+	// attributes that are not attached use the default options
+	attr := &HtmlAttribute{Name: "xml:lang"}
+	assert.Equal(t, XmlNamespace, attr.NamespaceURI())
+}
@@ -12,9 +12,11 @@
 package lhtml
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
-	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"golang.org/x/net/html"
@@ -22,112 +24,403 @@ import (
 
 const whitespace = " \t\r\n\f"
 
+//
+// The elements whose content the tokenizer reads as raw text,
+// unless told otherwise.
+//
+var tokenizerRawTextElements = toNameSet([]string{
+	"iframe", "noembed", "noframes", "noscript", "plaintext", "script", "style", "textarea", "title", "xmp",
+})
+
+//
+// Holds the state of a single parse run.
+//
+type parser struct {
+	handler       Handler           // receives the parsed nodes
+	stack         *nodeStack        // the open elements
+	reader        io.Reader         // the reader the tokenizer reads from
+	tokenizer     *html.Tokenizer   // the underlying tokenizer
+	rawText       *HtmlNode         // the raw text element whose content is to be read next
+	options       *ParseOptions     // the options in use
+	diagnostics   Diagnostics       // problems we recovered from
+	voids         map[string]bool   // lower-cased names of void elements
+	raw           string            // raw text of the current token
+	masked        string            // raw text of the current token with expressions masked
+	expressions   *expressionReader // masks template expressions, if any
+	braces        []*expression     // brace spans masked in the current tag
+	position      Position          // position where the current token starts
+	trivia        *string           // where to keep raw markup that is dropped
+	leadingTrivia string            // raw markup dropped before the first node
+}
+
 //
 // Generic parse function that takes the reader
 // and tries to return the `HtmlDocument` on a best-effort
 // basis.
 //
 func ParseWithOptions(reader io.Reader, options *ParseOptions) (*HtmlElements, error) {
-	// create a new stack
-	stack := newNodeStack()
+	result, err := ParseWithDiagnostics(reader, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Elements, nil
+}
+
+//
+// Parse the markup from the given reader and return the parsed
+// elements along with the diagnostics for every problem that was
+// encountered, and recovered from, during parsing.
+//
+// An error is returned only when the markup could not be read,
+// or if the options ask to fail on problems.
+//
+func ParseWithDiagnostics(reader io.Reader, options *ParseOptions) (*ParseResult, error) {
+	if options == nil {
+		options = getDefaultOptions()
+	}
+
+	builder := newTreeBuilder(options)
+	parser, err := parse(reader, builder, options)
+	if err != nil {
+		return nil, err
+	}
 
-	// create a tokenizer
-	tokenizer := html.NewTokenizer(reader)
+	builder.document.list().trivia = parser.leadingTrivia
+	return &ParseResult{
+		Elements:    builder.document,
+		Diagnostics: parser.diagnostics,
+	}, nil
+}
 
-	// create a document instance that we can use
-	document := NewHtmlElements()
+//
+// Parse the markup from the given reader, passing every node
+// to the given handler as soon as it is read. Returns the parser
+// once all input has been consumed.
+//
+func parse(reader io.Reader, handler Handler, options *ParseOptions) (*parser, error) {
+	if reader == nil {
+		return nil, errors.New("Reader is required to parse html.")
+	}
+
+	if handler == nil {
+		return nil, errors.New("Handler is required to parse html.")
+	}
+
+	if options == nil {
+		options = getDefaultOptions()
+	}
+
+	// template expressions are masked, so that the tokenizer
+	// never reads them
+	var expressions *expressionReader
+	if len(options.ExpressionDelimiters) > 0 {
+		expressions = newExpressionReader(reader, options.ExpressionDelimiters)
+		reader = expressions
+	}
+
+	parser := &parser{
+		handler:     handler,
+		stack:       newNodeStack(),
+		reader:      reader,
+		tokenizer:   html.NewTokenizer(reader),
+		options:     options,
+		diagnostics: make(Diagnostics, 0),
+		voids:       options.voidElements(),
+		position:    startPosition(),
+		expressions: expressions,
+	}
+	parser.trivia = &parser.leadingTrivia
 
 	// let's start parsing
 	for {
-		token := tokenizer.Next()
+		// content of raw text elements is read by us, and not the
+		// tokenizer, so that the elements can be configured
+		if parser.rawText != nil {
+			err := parser.readRawText()
+			if err != nil {
+				return nil, err
+			}
+
+			parser.advance()
+			continue
+		}
+
+		token := parser.tokenizer.Next()
+
+		// raw bytes are modified by the tokenizer when reading
+		// the token, so we keep a copy of them
+		parser.masked = string(parser.tokenizer.Raw())
+		parser.raw = parser.restore(parser.masked)
 
-		err := parseToken(document, tokenizer, &token, stack)
+		err := parser.parseToken(token)
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
 			return nil, err
 		}
+
+		parser.advance()
+	}
+
+	err := parser.closeOpenElements()
+	if err != nil {
+		return nil, err
 	}
 
-	return document, nil
+	return parser, nil
+}
+
+//
+// Move the position past the current token. The expressions within
+// the token are dropped, as they are never restored again.
+//
+func (parser *parser) advance() {
+	parser.position = parser.position.advance(parser.raw)
+	if parser.expressions != nil {
+		parser.expressions.discard(parser.position.Offset)
+	}
 }
 
 //
 // Parse the given token and return an error, if any.
 //
-func parseToken(document *HtmlElements, tokenizer *html.Tokenizer, token *html.TokenType, stack *nodeStack) error {
-	switch *token {
+func (parser *parser) parseToken(token html.TokenType) error {
+	switch token {
 
 	// handle the doctype token
 	case html.DoctypeToken:
-		return handleDocTypeToken(document, tokenizer)
+		return parser.handleDocTypeToken()
 
 	// handle error tokens
 	case html.ErrorToken:
-		return handleErrorToken(document, tokenizer)
+		return parser.handleErrorToken()
 
 	case html.TextToken:
-		return handleTextToken(document, stack, tokenizer)
+		return parser.handleTextToken()
 
-	// just add the comment as is
+	// add the comment where it appears
 	case html.CommentToken:
-		return handleCommentToken(document, tokenizer)
+		return parser.handleCommentToken()
 
 	// start of a token
 	case html.StartTagToken:
-		return handleStartTagToken(document, stack, tokenizer, false)
+		return parser.handleStartTagToken(false)
 
 	// self-sufficient token
 	case html.SelfClosingTagToken:
-		return handleStartTagToken(document, stack, tokenizer, true)
+		return parser.handleStartTagToken(true)
 
 	case html.EndTagToken:
-		handleEndTagToken(document, stack, tokenizer)
+		return parser.handleEndTagToken()
 	}
 
 	// all processed
 	return nil
 }
 
+//
+// Keep the raw markup of the current token, that is not added
+// to the tree, so that the source can be reproduced exactly.
+//
+func (parser *parser) drop() {
+	*parser.trivia += parser.raw
+}
+
+//
+// Keep the raw markup of the current token as the source of the
+// given node, along with a snapshot of the values read from it.
+//
+func (parser *parser) keepSource(node *HtmlNode) {
+	source := &nodeSource{
+		openTag:       parser.raw,
+		tagName:       node._tagName,
+		data:          node.Data,
+		isSelfClosing: node.IsSelfClosing,
+		attributes:    make([]HtmlAttribute, 0, len(node.Attributes)),
+	}
+	for _, attr := range node.Attributes {
+		source.attributes = append(source.attributes, *attr)
+	}
+
+	node._source = source
+	parser.trivia = &source.trivia
+}
+
+//
+// Record a problem that we recovered from.
+//
+func (parser *parser) report(code DiagnosticCode, severity Severity, message string, position Position) {
+	parser.diagnostics = append(parser.diagnostics, &Diagnostic{
+		Code:     code,
+		Severity: severity,
+		Message:  message,
+		Position: position,
+	})
+}
+
 //
 // Read an element node from the tokenizer. An element node is
 // basically a tag, such as `<br />`  or `<div ...>`.
 // This method reads the tag as well as any attributes assigned
 // to this tag.
 //
-func readElementNode(tokenizer *html.Tokenizer) *HtmlNode {
+func (parser *parser) readElementNode(tokenizer *html.Tokenizer, spans []braceSpan) *HtmlNode {
+	tag := parser.scanTag(spans)
+
+	// brace spans are restored just like expressions
+	parser.braces = make([]*expression, 0, len(spans))
+	for _, span := range spans {
+		parser.braces = append(parser.braces, &expression{
+			offset: parser.position.Offset + span.start,
+			source: parser.raw[span.start:span.end],
+		})
+	}
+	defer func() {
+		parser.braces = nil
+	}()
+
 	// when a tag starts, we read the tag name
 	tagName, hasAttributes := tokenizer.TagName()
 	node := HtmlNode{
-		_tagName:      string(tagName),
+		_tagName:      parser.restoreWithin(string(tagName), tag.nameStart, tag.nameEnd),
+		_rawTagName:   tag.name,
 		IsSelfClosing: false,
 		NodeType:      ElementNode,
+		_openTag:      rangeOf(parser.position, parser.raw),
+		_options:      parser.options,
 	}
 
-	// if this is title element, set that next is not raw tag
-	if strings.EqualFold(node._tagName, "title") {
-		tokenizer.NextIsNotRawText()
+	// copy attributes as needed
+	attributes := make([]html.Attribute, 0, len(tag.attributes))
+	for more := hasAttributes; more; {
+		var key, value []byte
+		key, value, more = tokenizer.TagAttr()
+		if key != nil && value != nil {
+			attributes = append(attributes, html.Attribute{Key: string(key), Val: string(value)})
+		}
 	}
 
-	// copy attributes as needed
-	if hasAttributes {
-		for {
-			key, value, more := tokenizer.TagAttr()
-			if key != nil && value != nil {
-				node.AddAttribute(string(key), string(value))
-			}
-			if !more {
-				break
-			}
+	// the raw attributes are only used if they are the same as
+	// the ones read by the tokenizer
+	paired := len(attributes) == len(tag.attributes)
+	for index, attribute := range attributes {
+		name, decoded := attribute.Key, attribute.Val
+		var raw *rawAttribute
+		if paired {
+			raw = tag.attributes[index]
+			name = parser.restoreWithin(name, raw.nameStart, raw.nameEnd)
+			decoded = parser.restoreWithin(decoded, raw.valueStart, raw.valueEnd)
 		}
+
+		parser.readAttribute(&node, name, decoded, raw)
 	}
 
 	return &node
 }
 
-func handleDocTypeToken(document *HtmlElements, tokenizer *html.Tokenizer) error {
-	docType := tokenizer.Token().Data
+//
+// Add the attribute read from the tokenizer to the given node.
+// The name is lower-cased by the tokenizer, so we use the raw
+// spelling when attributes are case-sensitive. The value read
+// from the tokenizer has its character references decoded, and
+// the raw value is kept alongside.
+//
+func (parser *parser) readAttribute(node *HtmlNode, name string, value string, raw *rawAttribute) {
+	if raw != nil && parser.options.CaseSensitiveAttributes {
+		name = raw.name
+	}
+
+	attr := &HtmlAttribute{
+		Name:          name,
+		_decodedValue: value,
+		_options:      parser.options,
+	}
+	if raw != nil {
+		attr._rawName = raw.name
+		attr._rawValue = raw.value
+		parser.setAttributeRanges(attr, raw)
+
+		switch {
+		case raw.spread:
+			attr.Name, attr._rawName = "", ""
+			attr.ValueStyle = SpreadValue
+
+		case raw.braced:
+			attr.ValueStyle = BracedValue
+
+		case !raw.hasValue:
+			attr.ValueStyle = NoValue
+
+		case raw.quote == '\'':
+			attr.ValueStyle = SingleQuotedValue
+
+		case raw.quote == 0:
+			attr.ValueStyle = UnquotedValue
+		}
+
+		// code within braces is never decoded
+		if raw.braced || raw.spread {
+			attr._decodedValue = raw.value
+		}
+	}
+
+	attr.Value = attr._decodedValue
+	if parser.options.ValueMode == RawValues && raw != nil {
+		attr.Value = attr._rawValue
+	}
+
+	// spread attributes have no name, and are never merged
+	if attr.ValueStyle == SpreadValue || parser.options.AllowMultipleAttributesWithSameName {
+		node.addAttribute(attr)
+		return
+	}
+
+	// merge the value into the attribute read before
+	existing := node.GetAttribute(name)
+	if existing == nil {
+		node.addAttribute(attr)
+		return
+	}
+
+	existing.merge(parser.options.DuplicateAttributePolicy, attr)
+	parser.report(DuplicateAttribute, SeverityWarning, "Attribute '"+attr.RawName()+"' is specified more than once on '"+node.RawNodeName()+"'", attr._nameRange.Start)
+}
+
+//
+// Set the source ranges of the given attribute from the raw
+// attribute read from the current token.
+//
+func (parser *parser) setAttributeRanges(attr *HtmlAttribute, raw *rawAttribute) {
+	nameStart := parser.position.advance(parser.raw[:raw.nameStart])
+	attr._nameRange = rangeOf(nameStart, raw.name)
+
+	if raw.hasValue {
+		valueStart := parser.position.advance(parser.raw[:raw.valueStart])
+		attr._valueRange = rangeOf(valueStart, raw.value)
+	}
+}
+
+//
+// Check if the given text is empty or only contains whitespace.
+//
+func isWhitespaceOnly(text string) bool {
+	return len(strings.TrimLeft(text, whitespace)) == 0
+}
+
+//
+// Return an empty range at the given position.
+//
+func emptyRange(position Position) Range {
+	return Range{
+		Start: position,
+		End:   position,
+	}
+}
+
+func (parser *parser) handleDocTypeToken() error {
+	docType := parser.restoreWithin(parser.tokenizer.Token().Data, 0, len(parser.raw))
 
 	// we currently do not parse doc type to reveal information
 	// so add it to data attribute
@@ -135,24 +428,23 @@ func handleDocTypeToken(document *HtmlElements, tokenizer *html.Tokenizer) error
 		Data:     docType,
 		NodeType: DoctypeNode,
 		_parent:  nil,
+		_openTag: rangeOf(parser.position, parser.raw),
+		_options: parser.options,
 	}
-	document.InsertFirst(&node)
-	return nil
+	parser.keepSource(&node)
+	return parser.handler.Doctype(&node)
 }
 
-func handleErrorToken(document *HtmlElements, tokenizer *html.Tokenizer) error {
-	err := tokenizer.Err()
-
+func (parser *parser) handleErrorToken() error {
 	// if we ran into end-of-file we will return from
-	// where ever we are
-	if err != nil && err == io.EOF {
-		return err
+	// where ever we are, otherwise the reader failed
+	// and we cannot recover from that
+	err := parser.tokenizer.Err()
+	if err == nil {
+		return errors.New("Tokenizer reported an error without a cause")
 	}
 
-	fmt.Println(err)
-
-	// TODO: handle other error scenarios
-	return errors.New("error not handled")
+	return err
 }
 
 //
@@ -160,68 +452,463 @@ func handleErrorToken(document *HtmlElements, tokenizer *html.Tokenizer) error {
 // this may be an attribute
 // or this may be some textnode as a child
 // lets process
-func handleTextToken(document *HtmlElements, stack *nodeStack, tokenizer *html.Tokenizer) error {
-	text := string(tokenizer.Text())
-	trimmedText := strings.TrimLeft(text, whitespace)
-	if len(trimmedText) == 0 {
+func (parser *parser) handleTextToken() error {
+	return parser.handleText(string(parser.tokenizer.Text()))
+}
+
+//
+// Add the raw text of the current token, with the given decoded
+// text, as a text node. Template expressions within the text are
+// added as expression nodes in between.
+//
+func (parser *parser) handleText(text string) error {
+	expressions := parser.within(0, len(parser.raw))
+	if len(expressions) == 0 {
+		return parser.addText(text)
+	}
+
+	// the text is split into text and expression nodes, each
+	// read as a token of its own
+	raw, position := parser.raw, parser.position
+	defer func() {
+		parser.raw, parser.position = raw, position
+	}()
+
+	parts := splitMasked(text, expressions)
+	start := 0
+	for index, expression := range expressions {
+		end := expression.offset - position.Offset
+		parser.raw = raw[start:end]
+		parser.position = position.advance(raw[:start])
+		err := parser.addText(parts[index])
+		if err != nil {
+			return err
+		}
+
+		parser.raw = expression.source
+		parser.position = position.advance(raw[:end])
+		err = parser.addExpression(expression)
+		if err != nil {
+			return err
+		}
+
+		start = end + len(expression.source)
+	}
+
+	parser.raw = raw[start:]
+	parser.position = position.advance(raw[:start])
+	return parser.addText(parts[len(expressions)])
+}
+
+//
+// Add a text node for the raw text of the current token, with the
+// given decoded text.
+//
+func (parser *parser) addText(text string) error {
+	if parser.raw == "" {
 		return nil
 	}
 
-	node := HtmlNode{
-		NodeType: TextNode,
-		Data:     text,
+	if !parser.options.PreserveWhitespace && isWhitespaceOnly(text) {
+		parser.drop()
+		return nil
 	}
-	document.addNodeToStack(&node, stack)
-	stack.pop()
 
-	return nil
+	node := newTextNode(parser.raw, text, parser.options)
+	node._openTag = rangeOf(parser.position, parser.raw)
+	parser.keepSource(node)
+	return parser.handler.Text(node)
+}
+
+//
+// Add an expression node for the given expression, which is the
+// current token.
+//
+func (parser *parser) addExpression(expression *expression) error {
+	node := NewExpression(expression.delimiters, expression.code())
+	node._openTag = rangeOf(parser.position, parser.raw)
+	node._options = parser.options
+	parser.keepSource(node)
+	return parser.handler.Text(node)
+}
+
+//
+// Scan the masked raw markup of the current start or end tag, with
+// the given brace spans masked and separated as well, just as it is
+// read by the tokenizer, and restore the expressions in the names
+// and values read.
+//
+func (parser *parser) scanTag(spans []braceSpan) *rawTag {
+	masked := maskBraces(parser.masked, spans)
+	separators := braceSeparators(masked, spans)
+	tag := scanRawTag(separateBraces(masked, separators))
+	unseparateTag(tag, separators)
+	if parser.expressions == nil && len(spans) == 0 {
+		return tag
+	}
+
+	tag.name = parser.raw[tag.nameStart:tag.nameEnd]
+	for _, attr := range tag.attributes {
+		attr.name = parser.raw[attr.nameStart:attr.nameEnd]
+		attr.value = parser.raw[attr.valueStart:attr.valueEnd]
+	}
+
+	markBracedAttributes(tag, parser.raw, spans)
+	return tag
+}
+
+//
+// Return the expressions that start within the given range of the
+// current token.
+//
+func (parser *parser) within(start int, end int) []*expression {
+	start += parser.position.Offset
+	end += parser.position.Offset
+
+	var expressions []*expression
+	if parser.expressions != nil {
+		expressions = parser.expressions.within(start, end)
+	}
+
+	if len(parser.braces) == 0 {
+		return expressions
+	}
+
+	// expressions within braces are masked along with the braces
+	found := make([]*expression, 0, len(expressions))
+	for _, expression := range expressions {
+		if !containsOffset(parser.braces, expression.offset) {
+			found = append(found, expression)
+		}
+	}
+
+	for _, brace := range parser.braces {
+		if brace.offset >= start && brace.offset < end {
+			found = append(found, brace)
+		}
+	}
+
+	sort.Slice(found, func(i int, j int) bool {
+		return found[i].offset < found[j].offset
+	})
+	return found
+}
+
+//
+// Check if the given offset is within any of the given expressions.
+//
+func containsOffset(expressions []*expression, offset int) bool {
+	for _, expression := range expressions {
+		if offset >= expression.offset && offset < expression.offset+len(expression.source) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// Restore the expressions in the given masked raw text of the
+// current token.
+//
+func (parser *parser) restore(masked string) string {
+	if parser.expressions == nil {
+		return masked
+	}
+
+	return parser.expressions.restore(masked, parser.position.Offset)
 }
 
-func handleCommentToken(document *HtmlElements, tokenizer *html.Tokenizer) error {
-	comment := tokenizer.Token().Data
+//
+// Restore the expressions in the given text, read from the given
+// range of the current token, that may have been decoded since.
+//
+func (parser *parser) restoreWithin(text string, start int, end int) string {
+	return restoreMasked(text, parser.within(start, end))
+}
+
+//
+// Read the content of the open raw text element, up to its end
+// tag, and add it as a single text node. The tokenizer is then
+// restarted right before the end tag.
+//
+func (parser *parser) readRawText() error {
+	element := parser.rawText
+	parser.rawText = nil
+
+	reader, source := parser.takeOver()
+	content := strings.Builder{}
+	for {
+		peeked, err := reader.Peek(len(element._tagName) + 3)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return err
+		}
+
+		if len(peeked) == 0 || isRawTextEnd(reader, element._tagName) {
+			break
+		}
+
+		// skip ahead to the next `</` in what has been read, keeping
+		// a `<` at the very end as it may start the end tag
+		buffered, _ := reader.Peek(reader.Buffered())
+		count := bytes.Index(buffered[1:], []byte("</")) + 1
+		if count == 0 {
+			count = len(buffered)
+			if count > 1 && buffered[count-1] == '<' {
+				count--
+			}
+		}
+
+		content.Write(buffered[:count])
+		_, err = reader.Discard(count)
+		if err != nil {
+			return err
+		}
+	}
+
+	parser.resume(reader, source)
+	parser.masked = content.String()
+	parser.raw = parser.restore(parser.masked)
+	if parser.raw == "" {
+		return nil
+	}
+
+	return parser.handleText(decodeRawText(element._tagName, parser.masked))
+}
+
+//
+// Take over reading the input from the tokenizer, right after the
+// current token. Returns a reader for the input along with the
+// source it reads from, which are to be passed to `resume()` once
+// done reading.
+//
+func (parser *parser) takeOver() (*bufio.Reader, io.Reader) {
+	// the tokenizer may have read ahead of the current token
+	buffered := string(parser.tokenizer.Buffered())
+	source := io.MultiReader(strings.NewReader(buffered), parser.reader)
+	return bufio.NewReader(source), source
+}
+
+//
+// Restart the tokenizer with the input that is yet to be read from
+// the given reader, taken over from the tokenizer.
+//
+func (parser *parser) resume(reader *bufio.Reader, source io.Reader) {
+	// continue with what has been read ahead, the readers are not
+	// nested as a multi-reader flattens the readers given to it
+	rest, _ := reader.Peek(reader.Buffered())
+	parser.reader = io.MultiReader(strings.NewReader(string(rest)), source)
+	parser.tokenizer = html.NewTokenizer(parser.reader)
+}
+
+//
+// Restart the tokenizer with the given text, that was read from
+// the given reader taken over from the tokenizer, followed by the
+// input that is yet to be read.
+//
+func (parser *parser) resumeWith(text string, reader *bufio.Reader, source io.Reader) {
+	rest, _ := reader.Peek(reader.Buffered())
+	parser.reader = io.MultiReader(strings.NewReader(text+string(rest)), source)
+	parser.tokenizer = html.NewTokenizer(parser.reader)
+}
+
+//
+// Check if the given reader continues with the end tag of the raw
+// text element with the given lower-cased name. The end tag must be
+// closed with `>`, so that an end tag cut short by the end of input
+// is kept as raw text.
+//
+func isRawTextEnd(reader *bufio.Reader, name string) bool {
+	end := len(name) + 2
+	peeked, _ := reader.Peek(end + 1)
+	if len(peeked) <= end || peeked[0] != '<' || peeked[1] != '/' || !strings.EqualFold(string(peeked[2:end]), name) {
+		return false
+	}
+
+	if peeked[end] == '>' {
+		return true
+	}
+
+	if !isWhitespace(peeked[end]) && peeked[end] != '/' {
+		return false
+	}
+
+	for size := 2 * (end + 1); ; size *= 2 {
+		peeked, err := reader.Peek(size)
+		if bytes.IndexByte(peeked[end:], '>') >= 0 {
+			return true
+		}
+
+		// the end tag is longer than can be read ahead
+		if len(peeked) < size {
+			return err == bufio.ErrBufferFull
+		}
+	}
+}
+
+func (parser *parser) handleCommentToken() error {
+	raw := parser.raw
+	if !strings.HasPrefix(raw, "<!--") || !strings.HasSuffix(raw, "-->") || len(raw) < len("<!---->") {
+		parser.report(MalformedComment, SeverityWarning, "Malformed comment: "+raw, parser.position)
+	}
+
+	comment := parser.restoreWithin(parser.tokenizer.Token().Data, 0, len(parser.raw))
 	node := HtmlNode{
 		Data:     comment,
 		NodeType: CommentNode,
+		_openTag: rangeOf(parser.position, parser.raw),
+		_options: parser.options,
 	}
-	document.appendNode(&node)
-	return nil
+	parser.keepSource(&node)
+	return parser.handler.Comment(&node)
 }
 
 //
 // for end token, we need to check if we have the right
 // start tag at the top of the stack. Otherwise we may
 // have to pop all the way down to see if we have a node
-// with the end name
+// with the end name, depending on the `EndTagRecovery`
+// configured in the options.
 //
-func handleEndTagToken(document *HtmlElements, stack *nodeStack, tokenizer *html.Tokenizer) error {
-	tagName, _ := tokenizer.TagName()
-
-	// if stack is empty, this is a wrong tag
+func (parser *parser) handleEndTagToken() error {
+	tagName, _ := parser.tokenizer.TagName()
+	tag := parser.scanTag(nil)
+	name := parser.restoreWithin(string(tagName), tag.nameStart, tag.nameEnd)
+	rawName := tag.name
+	stack := parser.stack
+
+	// if stack is empty, this is a stray end tag
 	if stack.isEmpty() {
-		return errors.New("Encountered end tag when stack is empty: " + string(tagName))
+		if parser.options.EndTagRecovery == FailOnMismatchedEndTag {
+			return errors.New("Encountered end tag when stack is empty: " + rawName)
+		}
+
+		parser.report(StrayEndTag, SeverityWarning, "Ignored end tag '"+rawName+"' as there is no open element", parser.position)
+		parser.drop()
+		return nil
 	}
 
 	// let's check what is at top
 	element := stack.peek()
-	if element._tagName == string(tagName) {
+	if strings.EqualFold(element._tagName, name) {
 		// its the same tag, let's just pop and move ahead
 		stack.pop()
-
-		return nil
+		return parser.closeElement(element)
 	}
 
 	// this is not the same tag as the one at the top of stack
 	// so we need to try and heal if we can, or just ignore this
-	// TODO: fix this
-	return errors.New("not implemented")
+	switch parser.options.EndTagRecovery {
+	case FailOnMismatchedEndTag:
+		return errors.New("Encountered end tag '" + rawName + "' while '" + element.RawNodeName() + "' is open")
+
+	case IgnoreMismatchedEndTag:
+		parser.report(StrayEndTag, SeverityWarning, "Ignored end tag '"+rawName+"' as '"+element.RawNodeName()+"' is open", parser.position)
+		parser.drop()
+		return nil
+	}
+
+	// find a matching open element down the stack, and if
+	// there is one close everything that is above it
+	index := stack.lastIndexOf(name)
+	if index < 0 {
+		parser.report(StrayEndTag, SeverityWarning, "Ignored end tag '"+rawName+"' as there is no matching open element", parser.position)
+		parser.drop()
+		return nil
+	}
+
+	popped := stack.popTo(index)
+	for _, node := range popped[:len(popped)-1] {
+		node._closeTag = emptyRange(parser.position)
+		parser.report(ImplicitlyClosedElement, SeverityWarning, "Element '"+node.RawNodeName()+"' was implicitly closed by end tag '"+rawName+"'", parser.position)
+
+		err := parser.handler.EndElement(node)
+		if err != nil {
+			return err
+		}
+	}
+
+	return parser.closeElement(popped[len(popped)-1])
 }
 
-func handleStartTagToken(document *HtmlElements, stack *nodeStack, tokenizer *html.Tokenizer, popElement bool) error {
-	node := readElementNode(tokenizer)
-	document.addNodeToStack(node, stack)
+//
+// Close the given element with the end tag read as the current
+// token.
+//
+func (parser *parser) closeElement(element *HtmlNode) error {
+	element._closeTag = rangeOf(parser.position, parser.raw)
+	if element._source != nil {
+		element._source.closeTag = parser.raw
+		parser.trivia = &element._source.trivia
+	}
 
-	if popElement {
-		stack.pop()
+	return parser.handler.EndElement(element)
+}
+
+//
+// for start token, we add the element to the tree and keep it
+// open, unless the tag was self-closing or the element is a void
+// element which can never have any content.
+//
+func (parser *parser) handleStartTagToken(selfClosing bool) error {
+	tokenizer := parser.tokenizer
+	var spans []braceSpan
+	if parser.options.JsxAttributes {
+		var err error
+		tokenizer, spans, selfClosing, err = parser.readJsxTag(selfClosing)
+		if err != nil {
+			return err
+		}
+	}
+
+	node := parser.readElementNode(tokenizer, spans)
+	node.IsSelfClosing = selfClosing
+	node.IsVoid = parser.voids[node._tagName]
+	parser.keepSource(node)
+
+	// the tokenizer has its own fixed set of raw text elements, but
+	// we read the content of the configured raw text elements
+	// ourselves, and parse the content of all other elements
+	rawText := !selfClosing && !node.IsVoid && parser.options.isRawTextElement(node._tagName)
+	if !rawText && tokenizerRawTextElements[node._tagName] {
+		parser.tokenizer.NextIsNotRawText()
+	}
+
+	err := parser.handler.StartElement(node)
+	if err != nil {
+		return err
+	}
+
+	if selfClosing || node.IsVoid {
+		node._closeTag = emptyRange(node._openTag.End)
+		return parser.handler.EndElement(node)
+	}
+
+	// anything dropped now is within this element
+	parser.stack.push(node)
+	parser.trivia = &node._source.innerTrivia
+
+	if rawText {
+		parser.rawText = node
+	}
+
+	return nil
+}
+
+//
+// Close all elements that are still open once the input has
+// been consumed completely.
+//
+func (parser *parser) closeOpenElements() error {
+	for !parser.stack.isEmpty() {
+		node := parser.stack.pop()
+		node._closeTag = emptyRange(parser.position)
+		parser.report(UnclosedElement, SeverityWarning, "Element '"+node.RawNodeName()+"' was not closed before end of input", parser.position)
+
+		err := parser.handler.EndElement(node)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
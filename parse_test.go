@@ -12,6 +12,7 @@
 package lhtml
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -22,7 +23,7 @@ func TestSimplestHtml(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.Equal(t, 1, doc.Length())
-	assert.Equal(t, 1, doc.nodes[0].NumChildren())
+	assert.Equal(t, 1, doc.Nodes()[0].NumChildren())
 }
 
 func TestOnlyString(t *testing.T) {
@@ -30,18 +31,49 @@ func TestOnlyString(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.Equal(t, 1, doc.Length())
-	assert.Equal(t, TextNode, doc.nodes[0].NodeType)
-	assert.Equal(t, "Hello World", doc.nodes[0].Data)
+	assert.Equal(t, TextNode, doc.Nodes()[0].NodeType)
+	assert.Equal(t, "Hello World", doc.Nodes()[0].Data)
 }
 
 func TestComment(t *testing.T) {
 	doc, err := getDoc("<!doctype html><html><!-- this is a comment --></html>")
 	assert.NoError(t, err)
 
+	assert.Equal(t, 2, doc.Length())
+	assert.Equal(t, DoctypeNode, doc.Nodes()[0].NodeType)
+	assert.Equal(t, 1, doc.Nodes()[1].NumChildren())
+
+	comment := doc.Nodes()[1].First()
+	assert.Equal(t, CommentNode, comment.NodeType)
+	assert.Equal(t, " this is a comment ", comment.Data)
+	assert.Equal(t, doc.Nodes()[1], comment.Parent())
+}
+
+func TestCommentInHead(t *testing.T) {
+	doc, err := getDoc("<!-- top --><html><head><title>x</title><!-- scripts --></head><body><!--[if IE]>old<![endif]--></body></html>")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, doc.Length())
+	assert.Equal(t, CommentNode, doc.First().NodeType)
+
+	head := doc.AsHtmlDocument().Head()
+	assert.Equal(t, 2, head.NumChildren())
+	assert.Equal(t, " scripts ", head.Get(1).Data)
+	assert.Equal(t, "[if IE]>old<![endif]", doc.AsHtmlDocument().Body().First().Data)
+}
+
+func TestFlatComments(t *testing.T) {
+	options := getDefaultOptions()
+	options.FlatComments = true
+
+	doc, err := ParseWithOptions(strings.NewReader("<!doctype html><html><!-- this is a comment --></html>"), options)
+	assert.NoError(t, err)
+
 	assert.Equal(t, 3, doc.Length())
-	assert.Equal(t, DoctypeNode, doc.nodes[0].NodeType)
-	assert.Equal(t, CommentNode, doc.nodes[2].NodeType)
-	assert.Equal(t, " this is a comment ", doc.nodes[2].Data)
+	assert.Equal(t, DoctypeNode, doc.Nodes()[0].NodeType)
+	assert.Equal(t, CommentNode, doc.Nodes()[2].NodeType)
+	assert.Equal(t, " this is a comment ", doc.Nodes()[2].Data)
+	assert.Nil(t, doc.Nodes()[2].Parent())
 }
 
 func TestEmptyText(t *testing.T) {
@@ -50,3 +82,263 @@ func TestEmptyText(t *testing.T) {
 
 	assert.Equal(t, 1, doc.Length())
 }
+
+func TestMismatchedEndTagClosesMatching(t *testing.T) {
+	doc, err := getDoc("<div><span><b>Hello</div><p>World</p>")
+	assert.NoError(t, err)
+
+	// `span` and `b` are implicitly closed by `</div>`
+	assert.Equal(t, 2, doc.Length())
+	assert.Equal(t, "div", doc.First().NodeName())
+	assert.Equal(t, "p", doc.Get(1).NodeName())
+	assert.Equal(t, "span", doc.First().First().NodeName())
+	assert.Equal(t, "b", doc.First().First().First().NodeName())
+}
+
+func TestStrayEndTagIsIgnored(t *testing.T) {
+	doc, err := getDoc("</div><html><body></section>Hello</body></html>")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, doc.Length())
+	body := doc.AsHtmlDocument().Body()
+	assert.NotNil(t, body)
+	assert.Equal(t, 1, body.NumChildren())
+	assert.Equal(t, TextNode, body.First().NodeType)
+}
+
+func TestIgnoreMismatchedEndTag(t *testing.T) {
+	options := getDefaultOptions()
+	options.EndTagRecovery = IgnoreMismatchedEndTag
+
+	doc, err := ParseWithOptions(strings.NewReader("<div><span>Hello</div><p>World</p></span></div>"), options)
+	assert.NoError(t, err)
+
+	// `</div>` is ignored while `span` is open
+	assert.Equal(t, 1, doc.Length())
+	span := doc.First().First()
+	assert.Equal(t, "span", span.NodeName())
+	assert.Equal(t, 2, span.NumChildren())
+	assert.Equal(t, "p", span.Get(1).NodeName())
+}
+
+func TestFailOnMismatchedEndTag(t *testing.T) {
+	options := getDefaultOptions()
+	options.EndTagRecovery = FailOnMismatchedEndTag
+
+	doc, err := ParseWithOptions(strings.NewReader("<div><span>Hello</div>"), options)
+	assert.Error(t, err)
+	assert.Nil(t, doc)
+
+	doc, err = ParseWithOptions(strings.NewReader("</div>"), options)
+	assert.Error(t, err)
+	assert.Nil(t, doc)
+
+	doc, err = ParseWithOptions(strings.NewReader("<div><span>Hello</span></div>"), options)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, doc.Length())
+}
+
+func TestParseWithNilOptions(t *testing.T) {
+	doc, err := ParseWithOptions(strings.NewReader("<div></div>"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, doc.Length())
+}
+
+func TestVoidElements(t *testing.T) {
+	doc, err := getDoc("<div><br><img src=x><input type='text'>Hello<meta charset='utf-8'></div><p>World</p>")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, doc.Length())
+	div := doc.First()
+	assert.Equal(t, 5, div.NumChildren())
+	assert.Equal(t, "br", div.Get(0).NodeName())
+	assert.True(t, div.Get(0).IsVoid)
+	assert.False(t, div.Get(0).IsSelfClosing)
+	assert.Equal(t, 0, div.Get(1).NumChildren())
+	assert.Equal(t, TextNode, div.Get(3).NodeType)
+	assert.False(t, div.IsVoid)
+
+	// explicitly self-closed
+	doc, err = getDoc("<br/><custom:Page />")
+	assert.NoError(t, err)
+	assert.True(t, doc.First().IsVoid)
+	assert.True(t, doc.First().IsSelfClosing)
+	assert.False(t, doc.Get(1).IsVoid)
+	assert.True(t, doc.Get(1).IsSelfClosing)
+}
+
+func TestStrayVoidEndTag(t *testing.T) {
+	result, err := ParseWithDiagnostics(strings.NewReader("<div><br></br>Hello</div>"), nil)
+	assert.NoError(t, err)
+
+	div := result.Elements.First()
+	assert.Equal(t, 2, div.NumChildren())
+	assert.Equal(t, 1, len(result.Diagnostics.WithCode(StrayEndTag)))
+}
+
+func TestCustomVoidElements(t *testing.T) {
+	options := getDefaultOptions()
+	options.VoidElements = append(DefaultVoidElements(), "custom:Icon")
+
+	doc, err := ParseWithOptions(strings.NewReader("<p><custom:Icon name='x'>Hello<br></p>"), options)
+	assert.NoError(t, err)
+
+	p := doc.First()
+	assert.Equal(t, 3, p.NumChildren())
+	assert.True(t, p.First().IsVoid)
+	assert.True(t, p.Get(2).IsVoid)
+
+	// override the defaults altogether
+	options.VoidElements = []string{}
+	doc, err = ParseWithOptions(strings.NewReader("<p><br>Hello</br></p>"), options)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, doc.First().NumChildren())
+	assert.Equal(t, 1, doc.First().First().NumChildren())
+
+	// the defaults cannot be changed through the copy
+	defaults := DefaultVoidElements()
+	defaults[0] = "p"
+	assert.Equal(t, "area", DefaultVoidElements()[0])
+}
+
+func TestPreserveWhitespace(t *testing.T) {
+	options := getDefaultOptions()
+	options.PreserveWhitespace = true
+
+	doc, err := ParseWithOptions(strings.NewReader("<p><b>a</b> <i>b</i></p>\n<pre>\n  x\n</pre>"), options)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3, doc.Length())
+	p := doc.First()
+	assert.Equal(t, 3, p.NumChildren())
+	assert.Equal(t, " ", p.Get(1).Data)
+	assert.True(t, p.Get(1).IsWhitespace())
+	assert.False(t, p.First().First().IsWhitespace())
+	assert.True(t, doc.Get(1).IsWhitespace())
+	assert.Equal(t, "\n  x\n", doc.Get(2).First().Data)
+
+	// by default whitespace-only text is dropped
+	doc, err = getDoc("<p><b>a</b> <i>b</i></p>")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, doc.First().NumChildren())
+
+	// blank documents
+	doc, err = ParseWithOptions(strings.NewReader("   "), options)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, doc.Length())
+}
+
+func TestRawTextElements(t *testing.T) {
+	doc, err := getDoc("<script>if (a < b && c) { x = '</p>' }</script><textarea><b>&amp;</b></textarea><style>p > b {}</STYLE >x")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 4, doc.Length())
+	script := doc.First()
+	assert.Equal(t, 1, script.NumChildren())
+	assert.Equal(t, "if (a < b && c) { x = '</p>' }", script.First().Data)
+
+	// escapable raw text has its references decoded
+	textarea := doc.Get(1)
+	assert.Equal(t, 1, textarea.NumChildren())
+	assert.Equal(t, "<b>&</b>", textarea.First().Data)
+	assert.Equal(t, "<b>&amp;</b>", textarea.First().RawData())
+
+	assert.Equal(t, "p > b {}", doc.Get(2).First().Data)
+	assert.Equal(t, "x", doc.Get(3).Data)
+
+	// raw text is written back as is, unless escapable
+	s, err := doc.String()
+	assert.NoError(t, err)
+	assert.Equal(t, "<script>if (a < b && c) { x = '</p>' }</script><textarea>&lt;b&gt;&amp;&lt;/b&gt;</textarea><style>p > b {}</style>x", s)
+
+	// the title is parsed inside by default
+	doc, err = getDoc("<title>Hello <custom:PageTitle /></title>")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, doc.First().NumChildren())
+	assert.Equal(t, "custom:pagetitle", doc.First().Last().NodeName())
+
+	// unclosed raw text consumes everything
+	result, err := ParseWithDiagnostics(strings.NewReader("<script>a</scripts><p>"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Elements.Length())
+	assert.Equal(t, "a</scripts><p>", result.Elements.First().First().Data)
+	assert.Equal(t, UnclosedElement, result.Diagnostics[0].Code)
+
+	// as does an end tag cut short by the end of input
+	for _, html := range []string{"<style>a</style", "<style>a</style \n"} {
+		result, err = ParseWithDiagnostics(strings.NewReader(html), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, html[len("<style>"):], result.Elements.First().First().Data)
+		assert.Equal(t, UnclosedElement, result.Diagnostics[0].Code)
+	}
+}
+
+func TestCustomRawTextElements(t *testing.T) {
+	options := getDefaultOptions()
+	options.RawTextElements = append(DefaultRawTextElements(), "custom:Code")
+	options.ParseInsideElements = []string{"noscript"}
+
+	html := "<custom:Code lang=go>if a < b { <p> }</custom:code><noscript><p>x</p></noscript>"
+	doc, err := ParseWithOptions(strings.NewReader(html), options)
+	assert.NoError(t, err)
+
+	code := doc.First()
+	assert.Equal(t, 1, code.NumChildren())
+	assert.Equal(t, "if a < b { <p> }", code.First().Data)
+	assert.Equal(t, "p", doc.Last().First().NodeName())
+
+	s, err := doc.String()
+	assert.NoError(t, err)
+	assert.Equal(t, "<custom:Code lang=\"go\">if a < b { <p> }</custom:Code><noscript><p>x</p></noscript>", s)
+
+	// fragments are not parsed either
+	assert.NoError(t, code.SetInnerHtml("<b>&amp;</b>"))
+	assert.Equal(t, 1, code.NumChildren())
+	assert.Equal(t, "<b>&amp;</b>", code.First().Data)
+
+	// positions continue after the raw text
+	assert.Equal(t, Position{Offset: 51, Line: 1, Column: 52}, doc.Last().OpenTagRange().Start)
+
+	// scripts can be parsed when not raw text
+	options.RawTextElements = []string{}
+	doc, err = ParseWithOptions(strings.NewReader("<script><b>x</b></script>"), options)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", doc.First().First().NodeName())
+}
+
+func TestManyRawTextElements(t *testing.T) {
+	builder := strings.Builder{}
+	for index := 0; index < 500; index++ {
+		builder.WriteString("<p>" + strings.Repeat("x", index) + "</p><script>" + strings.Repeat("<", index) + "</script>")
+	}
+
+	doc, err := getDoc(builder.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, doc.Length())
+	assert.Equal(t, strings.Repeat("<", 499), doc.Last().First().Data)
+
+	s, err := doc.String()
+	assert.NoError(t, err)
+	assert.Equal(t, builder.String(), s)
+}
+
+func TestLongRawText(t *testing.T) {
+	// end tags that are not the end, across the read buffer
+	for _, size := range []int{4090, 4093, 4094, 4095, 4096, 9000} {
+		content := strings.Repeat("a</b", size/4) + "</scrip" + strings.Repeat("<", size%7) + "</"
+		doc, err := getDoc("<script>" + content + "</SCRIPT ><p>x</p>")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, doc.Length())
+		assert.Equal(t, content, doc.First().First().Data)
+		assert.Equal(t, "p", doc.Last().NodeName())
+	}
+}
+
+func TestSelfClosingRawTextElement(t *testing.T) {
+	doc, err := getDoc("<script/><p>x</p><textarea /><b>y</b>")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, doc.Length())
+	assert.False(t, doc.First().HasChildren())
+	assert.Equal(t, "p", doc.Get(1).NodeName())
+	assert.Equal(t, "b", doc.Last().NodeName())
+}
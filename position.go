@@ -0,0 +1,105 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"strconv"
+)
+
+//
+// A location within the parsed source.
+//
+type Position struct {
+	Offset int // zero-based byte offset from the start of input
+	Line   int // one-based line number
+	Column int // one-based column number, counted in characters
+}
+
+//
+// A span within the parsed source. The start position is
+// inclusive and the end position is exclusive.
+//
+type Range struct {
+	Start Position // where the span starts
+	End   Position // where the span ends
+}
+
+//
+// The position of the very first character of the input.
+//
+func startPosition() Position {
+	return Position{
+		Offset: 0,
+		Line:   1,
+		Column: 1,
+	}
+}
+
+//
+// Return the position that is reached after reading the
+// given text starting at this position.
+//
+func (position Position) advance(text string) Position {
+	position.Offset += len(text)
+	for _, char := range text {
+		if char == '\n' {
+			position.Line++
+			position.Column = 1
+			continue
+		}
+
+		position.Column++
+	}
+
+	return position
+}
+
+//
+// Check if this position points into a parsed source. Nodes
+// and attributes that were created programmatically have no
+// valid position.
+//
+func (position Position) IsValid() bool {
+	return position.Line > 0
+}
+
+//
+// Return the position as `line:column`.
+//
+func (position Position) String() string {
+	return strconv.Itoa(position.Line) + ":" + strconv.Itoa(position.Column)
+}
+
+//
+// Check if this range points into a parsed source.
+//
+func (span Range) IsValid() bool {
+	return span.Start.IsValid()
+}
+
+//
+// Return the range as `line:column-line:column`.
+//
+func (span Range) String() string {
+	return span.Start.String() + "-" + span.End.String()
+}
+
+//
+// Return the range that covers the given text when it starts
+// at the given position.
+//
+func rangeOf(start Position, text string) Range {
+	return Range{
+		Start: start,
+		End:   start.advance(text),
+	}
+}
@@ -0,0 +1,88 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionAdvance(t *testing.T) {
+	position := startPosition().advance("ab\ncdé")
+	assert.Equal(t, 7, position.Offset)
+	assert.Equal(t, 2, position.Line)
+	assert.Equal(t, 4, position.Column)
+	assert.Equal(t, "2:4", position.String())
+	assert.True(t, position.IsValid())
+	assert.False(t, Position{}.IsValid())
+}
+
+func TestRangeOf(t *testing.T) {
+	span := rangeOf(startPosition(), "<div>")
+	assert.True(t, span.IsValid())
+	assert.Equal(t, 0, span.Start.Offset)
+	assert.Equal(t, 5, span.End.Offset)
+	assert.Equal(t, "1:1-1:6", span.String())
+	assert.False(t, Range{}.IsValid())
+}
+
+func TestNodePositions(t *testing.T) {
+	doc, err := getDoc("<html>\n  <body class=\"main\" hidden>Hello</body>\n  <p>text</html>")
+	assert.NoError(t, err)
+
+	html := doc.First()
+	assert.Equal(t, Range{Start: Position{0, 1, 1}, End: Position{6, 1, 7}}, html.OpenTagRange())
+	assert.Equal(t, Position{64, 3, 17}, html.CloseTagRange().End)
+	assert.Equal(t, 0, html.SourceRange().Start.Offset)
+	assert.Equal(t, 64, html.SourceRange().End.Offset)
+
+	body := html.First()
+	assert.Equal(t, Position{9, 2, 3}, body.OpenTagRange().Start)
+	assert.Equal(t, Position{35, 2, 29}, body.OpenTagRange().End)
+	assert.Equal(t, Position{40, 2, 34}, body.CloseTagRange().Start)
+	assert.Equal(t, Position{47, 2, 41}, body.CloseTagRange().End)
+
+	text := body.First()
+	assert.Equal(t, Range{Start: Position{35, 2, 29}, End: Position{40, 2, 34}}, text.SourceRange())
+
+	// attributes
+	class := body.GetAttribute("class")
+	assert.Equal(t, Range{Start: Position{15, 2, 9}, End: Position{20, 2, 14}}, class.NameRange())
+	assert.Equal(t, Range{Start: Position{22, 2, 16}, End: Position{26, 2, 20}}, class.ValueRange())
+
+	hidden := body.GetAttribute("hidden")
+	assert.True(t, hidden.NameRange().IsValid())
+	assert.False(t, hidden.ValueRange().IsValid())
+
+	// `p` is closed implicitly by `</html>`
+	p := html.Get(1)
+	assert.Equal(t, "p", p.NodeName())
+	assert.Equal(t, p.CloseTagRange().Start, p.CloseTagRange().End)
+	assert.Equal(t, 57, p.CloseTagRange().Start.Offset)
+}
+
+func TestSelfClosingPositions(t *testing.T) {
+	doc, err := getDoc("<custom:Page title='x' />")
+	assert.NoError(t, err)
+
+	node := doc.First()
+	assert.Equal(t, 25, node.OpenTagRange().End.Offset)
+	assert.Equal(t, 25, node.CloseTagRange().Start.Offset)
+	assert.Equal(t, 25, node.SourceRange().End.Offset)
+}
+
+func TestNoPositionsForNewNodes(t *testing.T) {
+	node := newNode("div")
+	assert.False(t, node.OpenTagRange().IsValid())
+	assert.False(t, node.SourceRange().IsValid())
+}
@@ -0,0 +1,358 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"io"
+	"strings"
+)
+
+//
+// Options that control how the nodes are written out as markup.
+//
+type RenderOptions struct {
+	// Write the original source for every node that was parsed and
+	// has not been modified since. When the tree was not modified at
+	// all, this reproduces the parsed input byte-for-byte, including
+	// any markup that the parser dropped. Attribute values of modified
+	// elements are written with the quotes used in source.
+	PreserveSource bool
+
+	// Write void elements that were not explicitly self-closed in
+	// source as `<br />` instead of `<br>`.
+	SelfCloseVoidElements bool
+
+	// Skip text nodes that only contain whitespace, unless they are
+	// part of the preserved source.
+	SkipWhitespace bool
+}
+
+//
+// Raw text elements whose text content has its character references
+// decoded, and is escaped when written.
+//
+var escapableRawTextElements = toNameSet([]string{
+	"textarea", "title",
+})
+
+//
+// The raw source of a parsed node, along with a snapshot of the
+// values read from it. The snapshot allows us to find if the node
+// was modified after parsing.
+//
+type nodeSource struct {
+	openTag       string          // the raw open tag, or the raw text, comment or doctype
+	closeTag      string          // the raw close tag, empty if closed implicitly or self-closing
+	innerTrivia   string          // raw markup dropped by the parser right after the open tag
+	trivia        string          // raw markup dropped by the parser right after this node
+	tagName       string          // the tag name when parsed
+	data          string          // the data when parsed
+	isSelfClosing bool            // the self-closing flag when parsed
+	attributes    []HtmlAttribute // the attributes when parsed
+}
+
+//
+// Return a copy of this source that shares nothing with it.
+//
+func (source *nodeSource) clone() *nodeSource {
+	copied := *source
+	copied.attributes = append([]HtmlAttribute(nil), source.attributes...)
+	return &copied
+}
+
+//
+// Write this list of elements as markup to the given writer.
+//
+func (elements *HtmlElements) Render(writer io.Writer, options RenderOptions) error {
+	renderer := &renderer{
+		writer:  writer,
+		options: options,
+	}
+
+	if options.PreserveSource {
+		renderer.write(elements.list().trivia)
+	}
+
+	for _, node := range elements.list().nodes {
+		renderer.render(node, false)
+	}
+
+	return renderer.err
+}
+
+//
+// Write this node, and all its children, as markup to the given
+// writer.
+//
+func (node *HtmlNode) Render(writer io.Writer, options RenderOptions) error {
+	renderer := &renderer{
+		writer:  writer,
+		options: options,
+	}
+
+	rawText := node.inRawTextElement()
+	renderer.render(node, rawText)
+	return renderer.err
+}
+
+//
+// Holds the state when writing nodes as markup.
+//
+type renderer struct {
+	writer  io.Writer     // where to write the markup
+	options RenderOptions // the options in use
+	err     error         // the first error encountered when writing
+}
+
+//
+// Write the given string unless an error has already been
+// encountered.
+//
+func (renderer *renderer) write(value string) {
+	if renderer.err != nil || value == "" {
+		return
+	}
+
+	_, renderer.err = io.WriteString(renderer.writer, value)
+}
+
+//
+// Write the given node. The `rawText` flag indicates that the node
+// is a child of an element whose text must not be escaped.
+//
+func (renderer *renderer) render(node *HtmlNode, rawText bool) {
+	source := node._source
+	if !renderer.options.PreserveSource {
+		source = nil
+	}
+
+	switch node.NodeType {
+	case TextNode:
+		switch {
+		case source != nil && source.data == node.Data:
+			renderer.write(source.openTag)
+
+		case renderer.options.SkipWhitespace && node.IsWhitespace():
+			// nothing to write
+
+		case rawText:
+			renderer.write(node.Data)
+
+		default:
+			renderer.write(node.markupData())
+		}
+
+	case CommentNode:
+		if source != nil && source.data == node.Data {
+			renderer.write(source.openTag)
+		} else {
+			renderer.write("<!--" + node.Data + "-->")
+		}
+
+	case ExpressionNode:
+		if source != nil && source.data == node.Data {
+			renderer.write(source.openTag)
+		} else {
+			renderer.write(node.ExpressionSource())
+		}
+
+	case DoctypeNode:
+		if source != nil && source.data == node.Data {
+			renderer.write(source.openTag)
+		} else {
+			renderer.write("<!DOCTYPE " + node.Data + ">")
+		}
+
+	case ElementNode:
+		renderer.renderElement(node, source)
+	}
+
+	if source != nil {
+		renderer.write(source.trivia)
+	}
+}
+
+//
+// Write the given element node along with its children.
+//
+func (renderer *renderer) renderElement(node *HtmlNode, source *nodeSource) {
+	name := node.RawNodeName()
+	empty := !node.HasChildren()
+	childless := node.IsSelfClosing || node.IsVoid
+
+	// the open tag
+	if source != nil && source.isUnmodified(node) && (empty || !childless) {
+		renderer.write(source.openTag)
+		if empty && childless {
+			return
+		}
+	} else {
+		renderer.write("<" + name)
+		for _, attr := range node.Attributes {
+			renderer.write(" " + attr.markup(renderer.options.PreserveSource))
+		}
+
+		if empty && node.IsSelfClosing {
+			renderer.write(" />")
+			return
+		}
+
+		if empty && node.IsVoid {
+			if renderer.options.SelfCloseVoidElements {
+				renderer.write(" />")
+			} else {
+				renderer.write(">")
+			}
+			return
+		}
+
+		renderer.write(">")
+	}
+
+	if source != nil {
+		renderer.write(source.innerTrivia)
+	}
+
+	// the children
+	rawText := node.hasRawText()
+	for _, child := range node._children {
+		renderer.render(child, rawText)
+	}
+
+	// the close tag, which is missing in source if the
+	// element was closed implicitly
+	if source != nil && source.tagName == node._tagName && !childless {
+		renderer.write(source.closeTag)
+		return
+	}
+
+	renderer.write("</" + name + ">")
+}
+
+//
+// Check if the open tag of the given node is still the same as
+// the one that was parsed.
+//
+func (source *nodeSource) isUnmodified(node *HtmlNode) bool {
+	if source.tagName != node._tagName || source.isSelfClosing != node.IsSelfClosing {
+		return false
+	}
+
+	if len(source.attributes) != len(node.Attributes) {
+		return false
+	}
+
+	for index, attr := range node.Attributes {
+		parsed := source.attributes[index]
+		if parsed.Name != attr.Name || parsed.Value != attr.Value || parsed.ValueStyle != attr.ValueStyle {
+			return false
+		}
+	}
+
+	return true
+}
+
+var textEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+)
+
+var attributeValueEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"\"", "&quot;",
+)
+
+var singleQuotedValueEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"'", "&#39;",
+)
+
+//
+// Return this attribute as it is written in markup. Boolean, braced
+// and spread attributes are always written as such, while values are
+// written within double quotes unless the quotes are preserved.
+//
+func (attr *HtmlAttribute) markup(preserveQuotes bool) string {
+	switch attr.ValueStyle {
+	case BracedValue:
+		return attr.RawName() + "={" + attr.Value + "}"
+
+	case SpreadValue:
+		return "{..." + attr.Value + "}"
+
+	case NoValue:
+		if attr.Value == "" {
+			return attr.RawName()
+		}
+
+	case SingleQuotedValue:
+		if preserveQuotes && attr.valueQuote('\'') == '\'' {
+			return attr.RawName() + "='" + attr.markupValue('\'') + "'"
+		}
+
+	case UnquotedValue:
+		value := attr.markupValue(0)
+		if preserveQuotes && value != "" && !strings.ContainsAny(value, whitespace+"\"'=<>`") {
+			return attr.RawName() + "=" + value
+		}
+	}
+
+	if attr.valueQuote('"') == '\'' {
+		return attr.RawName() + "='" + attr.markupValue('\'') + "'"
+	}
+
+	return attr.RawName() + "=\"" + attr.markupValue('"') + "\""
+}
+
+//
+// Return the quote to write the value of this attribute within. The
+// given quote is used unless a template expression in the value
+// contains it, as expressions are written without escaping. When
+// the expressions contain both quotes, the given quote is used as
+// the parser never looks for the end of a value within expressions.
+//
+func (attr *HtmlAttribute) valueQuote(preferred byte) byte {
+	other := byte('"')
+	if preferred == '"' {
+		other = '\''
+	}
+
+	code := ""
+	for _, part := range attr.ValueParts() {
+		if part.IsExpression {
+			code += part.Data
+		}
+	}
+
+	if strings.IndexByte(code, preferred) < 0 || strings.IndexByte(code, other) >= 0 {
+		return preferred
+	}
+
+	return other
+}
+
+//
+// Escape the given text so that it can be written as the content
+// of an element.
+//
+func escapeText(text string) string {
+	return textEscaper.Replace(text)
+}
+
+//
+// Escape the given value so that it can be written within double
+// quotes as the value of an attribute.
+//
+func escapeAttributeValue(value string) string {
+	return attributeValueEscaper.Replace(value)
+}
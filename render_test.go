@@ -0,0 +1,145 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func renderElements(t *testing.T, elements *HtmlElements, options RenderOptions) string {
+	builder := strings.Builder{}
+	assert.NoError(t, elements.Render(&builder, options))
+	return builder.String()
+}
+
+func TestRenderPreservesSource(t *testing.T) {
+	inputs := []string{
+		"",
+		"   ",
+		"Hello &amp; World",
+		"<!DOCTYPE html>\n<html lang=en>\n  <head><TITLE>Tom &amp; Jerry</TITLE></head>\n  <body CLASS = 'a'  hidden>\n    <br><img src=x.png/>\n  </body>\n</HTML>\n",
+		"<div><span>unclosed</div></span> trailing",
+		"<custom:PageBody custom:Title=\"x &quot;y&quot;\"><p>a<p>b</custom:PageBody>",
+		"<script>if (a < b && c) {}</script><style>a > b {}</style>",
+		"<html><head><!-- marker --></head><body>x<!----></body></html>",
+		"<!bogus><!-- unterminated",
+		"<!-- generated -->\n<!DOCTYPE html>\n<html><body>x</body></html>",
+		"<style>a</style",
+		"<script>a</script \n",
+	}
+
+	for _, input := range inputs {
+		elements, err := getDoc(input)
+		assert.NoError(t, err)
+		assert.Equal(t, input, renderElements(t, elements, RenderOptions{PreserveSource: true}))
+	}
+}
+
+func TestRenderCanonical(t *testing.T) {
+	elements, err := getDoc("<!doctype html><html LANG=en><body class='a \"b\"'>Tom &amp; Jerry &lt;3<br><custom:Page /><!-- c --></body></html>")
+	assert.NoError(t, err)
+
+	expected := "<!DOCTYPE html><html LANG=\"en\"><body class=\"a &quot;b&quot;\">Tom &amp; Jerry &lt;3<br><custom:Page /><!-- c --></body></html>"
+	assert.Equal(t, expected, renderElements(t, elements, RenderOptions{}))
+
+	// void elements as self-closed
+	elements, err = getDoc("<p>a<br>b<hr/></p>")
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>a<br />b<hr /></p>", renderElements(t, elements, RenderOptions{SelfCloseVoidElements: true}))
+	assert.Equal(t, "<p>a<br>b<hr /></p>", renderElements(t, elements, RenderOptions{}))
+}
+
+func TestRenderRawText(t *testing.T) {
+	elements, err := getDoc("<script>if (a < b && c) {}</script>")
+	assert.NoError(t, err)
+	assert.Equal(t, "<script>if (a < b && c) {}</script>", renderElements(t, elements, RenderOptions{}))
+
+	builder := strings.Builder{}
+	assert.NoError(t, elements.First().First().Render(&builder, RenderOptions{}))
+	assert.Equal(t, "if (a < b && c) {}", builder.String())
+}
+
+func TestRenderModifiedTree(t *testing.T) {
+	elements, err := getDoc("<div  id=a>\n  <p>Hello</p>\n  <custom:Item/>\n</div>")
+	assert.NoError(t, err)
+
+	div := elements.First()
+	div.SetAttribute("class", "x")
+	div.First().First().Data = "Bye & see you"
+	item := div.Get(1)
+	item.InsertChildAt(0, NewElement("b"))
+
+	// the quotes of attributes are kept
+	expected := "<div id=a class=\"x\">\n  <p>Bye &amp; see you</p>\n  <custom:Item><b></b></custom:Item>\n</div>"
+	assert.Equal(t, expected, renderElements(t, elements, RenderOptions{PreserveSource: true}))
+}
+
+func TestRenderAttributeExpressions(t *testing.T) {
+	elements := getTemplate(t, `<a href="{{ a && b }}&x=1" title='{{ "x" }}' data-x="{{ a < b }}" data-y='{{ '"' }}'>x</a>`)
+	a := elements.First()
+
+	// expressions are written as is, within a quote they do not contain
+	expected := `<a href="{{ a && b }}&amp;x=1" title='{{ "x" }}' data-x="{{ a < b }}" data-y="{{ '"' }}">x</a>`
+	assert.Equal(t, expected, a.String())
+
+	// the single quotes are kept, unless the expression needs others
+	a.SetAttribute("title", `{{ 'x' }} & y`)
+	expected = `<a href="{{ a && b }}&amp;x=1" title="{{ 'x' }} &amp; y" data-x="{{ a < b }}" data-y='{{ '"' }}'>x</a>`
+	assert.Equal(t, expected, renderElements(t, elements, RenderOptions{PreserveSource: true}))
+
+	// and the written markup is read back the same
+	reparsed := getTemplate(t, a.String()).First()
+	for _, attr := range a.Attributes {
+		assert.Equal(t, attr.Value, reparsed.GetAttribute(attr.Name).Value)
+	}
+}
+
+func TestNodeString(t *testing.T) {
+	elements, err := getDoc("<html><head /><body>Hello</body></html>")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "<body>Hello</body>", elements.First().Get(1).String())
+
+	s, err := elements.String()
+	assert.NoError(t, err)
+	assert.Equal(t, "<html><head /><body>Hello</body></html>", s)
+}
+
+type failingWriter struct{}
+
+func (writer failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestRenderWriterFailure(t *testing.T) {
+	elements, err := getDoc("<html><body>Hello</body></html>")
+	assert.NoError(t, err)
+
+	assert.Error(t, elements.Render(failingWriter{}, RenderOptions{}))
+	assert.Error(t, elements.First().Render(failingWriter{}, RenderOptions{}))
+}
+
+func TestRenderWhitespace(t *testing.T) {
+	options := getDefaultOptions()
+	options.PreserveWhitespace = true
+
+	elements, err := ParseWithOptions(strings.NewReader("<p><b>a</b> <i>b</i></p>\n"), options)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "<p><b>a</b> <i>b</i></p>\n", renderElements(t, elements, RenderOptions{}))
+	assert.Equal(t, "<p><b>a</b><i>b</i></p>", renderElements(t, elements, RenderOptions{SkipWhitespace: true}))
+	assert.Equal(t, "<p><b>a</b> <i>b</i></p>\n", renderElements(t, elements, RenderOptions{SkipWhitespace: true, PreserveSource: true}))
+}
@@ -0,0 +1,176 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+//
+// An attribute as it was read from the raw markup of a tag.
+// All offsets are relative to the start of the raw tag.
+//
+type rawAttribute struct {
+	name       string // the name as spelled in source
+	value      string // the value as spelled in source, without quotes
+	nameStart  int    // offset where the name starts
+	nameEnd    int    // offset where the name ends
+	valueStart int    // offset where the value starts
+	valueEnd   int    // offset where the value ends
+	hasValue   bool   // whether the name was followed by `=`
+	quote      byte   // the quote around the value, zero if not quoted
+	braced     bool   // whether the value is wrapped in braces, as in JSX
+	spread     bool   // whether this is a spread attribute, such as `{...props}`
+}
+
+//
+// A tag as it was read from the raw markup.
+//
+type rawTag struct {
+	name       string          // the tag name as spelled in source
+	nameStart  int             // offset where the name starts
+	nameEnd    int             // offset where the name ends
+	attributes []*rawAttribute // the attributes in order of appearance
+}
+
+//
+// Scan the raw markup of a start or end tag, such as `<div a="b">`,
+// and return the tag name and attributes along with their offsets.
+// The scanning rules are the same as the ones of the `html.Tokenizer`
+// so that the attributes returned map one-to-one to the ones read
+// via `TagAttr()`.
+//
+func scanRawTag(raw string) *rawTag {
+	tag := &rawTag{
+		attributes: make([]*rawAttribute, 0),
+	}
+
+	// skip `<` or `</`
+	index := 1
+	if index < len(raw) && raw[index] == '/' {
+		index++
+	}
+
+	// read the tag name
+	tag.nameStart = index
+	for index < len(raw) && !isWhitespace(raw[index]) && raw[index] != '/' && raw[index] != '>' {
+		index++
+	}
+	tag.nameEnd = index
+	tag.name = raw[tag.nameStart:tag.nameEnd]
+
+	// read all attributes
+	index = skipWhitespace(raw, index)
+	for index < len(raw) && raw[index] != '>' {
+		attr := &rawAttribute{}
+
+		// the attribute key
+		attr.nameStart = index
+		for index < len(raw) {
+			c := raw[index]
+			if isWhitespace(c) || c == '/' {
+				attr.nameEnd = index
+				index++
+				break
+			}
+
+			if c == '=' || c == '>' {
+				attr.nameEnd = index
+				break
+			}
+
+			index++
+			attr.nameEnd = index
+		}
+
+		// the attribute value
+		index = scanRawAttributeValue(raw, index, attr)
+
+		if attr.nameStart != attr.nameEnd {
+			attr.name = raw[attr.nameStart:attr.nameEnd]
+			attr.value = raw[attr.valueStart:attr.valueEnd]
+			tag.attributes = append(tag.attributes, attr)
+		}
+
+		index = skipWhitespace(raw, index)
+	}
+
+	return tag
+}
+
+//
+// Scan the value of an attribute that starts at the given index,
+// if any. Returns the index after the value.
+//
+func scanRawAttributeValue(raw string, index int, attr *rawAttribute) int {
+	attr.valueStart = index
+	attr.valueEnd = index
+
+	index = skipWhitespace(raw, index)
+	if index >= len(raw) || raw[index] != '=' {
+		return index
+	}
+
+	attr.hasValue = true
+	index = skipWhitespace(raw, index+1)
+	attr.valueStart = index
+	attr.valueEnd = index
+	if index >= len(raw) {
+		return index
+	}
+
+	quote := raw[index]
+	switch quote {
+	case '>':
+		return index
+
+	case '\'', '"':
+		index++
+		attr.quote = quote
+		attr.valueStart = index
+		for index < len(raw) && raw[index] != quote {
+			index++
+		}
+		attr.valueEnd = index
+		if index < len(raw) {
+			index++
+		}
+		return index
+	}
+
+	attr.valueStart = index
+	for index < len(raw) && !isWhitespace(raw[index]) && raw[index] != '>' {
+		index++
+	}
+	attr.valueEnd = index
+	return index
+}
+
+//
+// Check if the given byte is HTML whitespace.
+//
+func isWhitespace(c byte) bool {
+	switch c {
+	case ' ', '\n', '\r', '\t', '\f':
+		return true
+	}
+
+	return false
+}
+
+//
+// Return the index of the first non-whitespace byte at or
+// after the given index.
+//
+func skipWhitespace(raw string, index int) int {
+	for index < len(raw) && isWhitespace(raw[index]) {
+		index++
+	}
+
+	return index
+}
@@ -0,0 +1,54 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanRawTag(t *testing.T) {
+	tag := scanRawTag("<Custom:Page a=1 B = \"two\" c='3' / d>")
+	assert.Equal(t, "Custom:Page", tag.name)
+	assert.Equal(t, 1, tag.nameStart)
+	assert.Equal(t, 12, tag.nameEnd)
+	assert.Equal(t, 4, len(tag.attributes))
+
+	assert.Equal(t, "a", tag.attributes[0].name)
+	assert.Equal(t, "1", tag.attributes[0].value)
+	assert.Equal(t, "B", tag.attributes[1].name)
+	assert.Equal(t, "two", tag.attributes[1].value)
+	assert.Equal(t, 22, tag.attributes[1].valueStart)
+	assert.Equal(t, "c", tag.attributes[2].name)
+	assert.Equal(t, "3", tag.attributes[2].value)
+	assert.Equal(t, "d", tag.attributes[3].name)
+	assert.False(t, tag.attributes[3].hasValue)
+}
+
+func TestScanRawEndTag(t *testing.T) {
+	tag := scanRawTag("</Div >")
+	assert.Equal(t, "Div", tag.name)
+	assert.Equal(t, 0, len(tag.attributes))
+}
+
+func TestScanRawTagUnterminated(t *testing.T) {
+	tag := scanRawTag("<div a=\"b")
+	assert.Equal(t, "div", tag.name)
+	assert.Equal(t, 1, len(tag.attributes))
+	assert.Equal(t, "b", tag.attributes[0].value)
+
+	tag = scanRawTag("<div a=")
+	assert.Equal(t, 1, len(tag.attributes))
+	assert.True(t, tag.attributes[0].hasValue)
+	assert.Equal(t, "", tag.attributes[0].value)
+}
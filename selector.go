@@ -0,0 +1,1067 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+//
+// A compiled CSS selector that can be matched against nodes.
+// The following is supported:
+//
+//   - type, universal, `#id` and `.class` selectors
+//   - attribute selectors: `[a]`, `[a=v]`, `[a~=v]`, `[a|=v]`, `[a^=v]`,
+//     `[a$=v]` and `[a*=v]`, with an optional `i` flag for case-insensitive
+//     matching of values
+//   - descendant, child (`>`), next-sibling (`+`) and subsequent-sibling
+//     (`~`) combinators
+//   - grouping of selectors via `,`
+//   - pseudo-classes: `:root`, `:empty`, `:first-child`, `:last-child`,
+//     `:only-child`, `:nth-child()`, `:nth-last-child()`, `:not()`
+//     and `:has()`
+//
+// Names may contain escaped characters, such as `custom\:PageBody`.
+// Tag names are always matched case-insensitively.
+//
+type Selector struct {
+	source    string             // the selector as provided
+	selectors []*complexSelector // the grouped selectors
+}
+
+//
+// Compile the given CSS selector so that it can be matched against
+// nodes multiple times.
+//
+// Returns an error if the selector is not valid.
+//
+func CompileSelector(selector string) (*Selector, error) {
+	parser := &selectorParser{
+		source: selector,
+	}
+
+	selectors, err := parser.parseSelectorList(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if !parser.atEnd() {
+		return nil, parser.error("unexpected character '" + string(parser.source[parser.index]) + "'")
+	}
+
+	return &Selector{
+		source:    selector,
+		selectors: selectors,
+	}, nil
+}
+
+//
+// Return the selector as it was provided.
+//
+func (selector *Selector) String() string {
+	return selector.source
+}
+
+//
+// Check if the given node matches this selector.
+//
+func (selector *Selector) Match(node *HtmlNode) bool {
+	if node == nil {
+		return false
+	}
+
+	return matchAny(selector.selectors, node)
+}
+
+//----- Query methods
+
+//
+// Check if this node matches the given CSS selector.
+//
+// Returns an error if the selector is not valid.
+//
+func (node *HtmlNode) Matches(selector string) (bool, error) {
+	compiled, err := CompileSelector(selector)
+	if err != nil {
+		return false, err
+	}
+
+	return compiled.Match(node), nil
+}
+
+//
+// Return the first node within this node's descendants that
+// matches the given CSS selector. The node itself is never
+// matched, but its ancestors are considered when matching.
+//
+// Returns `nil` if no node matches, and an error if the selector
+// is not valid.
+//
+func (node *HtmlNode) Query(selector string) (*HtmlNode, error) {
+	compiled, err := CompileSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryFirst(node._children, compiled), nil
+}
+
+//
+// Return all nodes within this node's descendants that match the
+// given CSS selector, in document order. The node itself is never
+// matched, but its ancestors are considered when matching.
+//
+// Returns an error if the selector is not valid. If no node matches
+// an empty list is returned.
+//
+func (node *HtmlNode) QueryAll(selector string) (*HtmlElements, error) {
+	compiled, err := CompileSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	result := NewHtmlElements()
+	queryAll(node._children, compiled, result)
+	return result, nil
+}
+
+//
+// Return the first node within this list of elements, and their
+// descendants, that matches the given CSS selector.
+//
+// Returns `nil` if no node matches, and an error if the selector
+// is not valid.
+//
+func (elements *HtmlElements) Query(selector string) (*HtmlNode, error) {
+	compiled, err := CompileSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryFirst(elements.list().nodes, compiled), nil
+}
+
+//
+// Return all nodes within this list of elements, and their
+// descendants, that match the given CSS selector, in document
+// order.
+//
+// Returns an error if the selector is not valid. If no node matches
+// an empty list is returned.
+//
+func (elements *HtmlElements) QueryAll(selector string) (*HtmlElements, error) {
+	compiled, err := CompileSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	result := NewHtmlElements()
+	queryAll(elements.list().nodes, compiled, result)
+	return result, nil
+}
+
+//
+// Find the first node in the given nodes, or their descendants,
+// that matches the selector.
+//
+func queryFirst(nodes []*HtmlNode, selector *Selector) *HtmlNode {
+	for _, node := range nodes {
+		if selector.Match(node) {
+			return node
+		}
+
+		found := queryFirst(node._children, selector)
+		if found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+//
+// Collect all nodes in the given nodes, or their descendants, that
+// match the selector. The nodes are collected without detaching
+// them from the tree.
+//
+func queryAll(nodes []*HtmlNode, selector *Selector, result *HtmlElements) {
+	for _, node := range nodes {
+		if selector.Match(node) {
+			result.collect(node)
+		}
+
+		queryAll(node._children, selector, result)
+	}
+}
+
+//----- matching
+
+//
+// A sequence of compound selectors joined by combinators, such
+// as `div > p.intro a`.
+//
+type complexSelector struct {
+	compounds   []*compoundSelector // the compound selectors from left to right
+	combinators []byte              // the combinator between compounds[i] and compounds[i+1]
+	leading     byte                // the combinator relative to the subject of `:has()`, if any
+}
+
+//
+// A sequence of simple selectors that all apply to a single node,
+// such as `p.intro[lang]`.
+//
+type compoundSelector struct {
+	tagName  string        // the lower-cased tag name, empty for any
+	matchers []nodeMatcher // all other simple selectors
+}
+
+//
+// A simple selector that checks a single condition on a node.
+//
+type nodeMatcher func(node *HtmlNode) bool
+
+//
+// Check if any of the given selectors matches the node.
+//
+func matchAny(selectors []*complexSelector, node *HtmlNode) bool {
+	for _, complex := range selectors {
+		if complex.matchAt(len(complex.compounds)-1, node, nil) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// Check if the compound selector matches the given node.
+//
+func (compound *compoundSelector) matches(node *HtmlNode) bool {
+	if node.NodeType != ElementNode {
+		return false
+	}
+
+	if compound.tagName != "" && strings.ToLower(node.NodeName()) != compound.tagName {
+		return false
+	}
+
+	for _, matcher := range compound.matchers {
+		if !matcher(node) {
+			return false
+		}
+	}
+
+	return true
+}
+
+//
+// Check if the compound at the given index matches the node, and
+// all compounds to its left match the related nodes. The anchor is
+// the subject of a `:has()` pseudo-class that the left-most compound
+// must be related to, if any.
+//
+func (complex *complexSelector) matchAt(index int, node *HtmlNode, anchor *HtmlNode) bool {
+	if !complex.compounds[index].matches(node) {
+		return false
+	}
+
+	if index == 0 {
+		if anchor == nil {
+			return true
+		}
+
+		return isRelated(complex.leading, anchor, node)
+	}
+
+	switch complex.combinators[index-1] {
+	case ' ':
+		for ancestor := node._parent; ancestor != nil; ancestor = ancestor._parent {
+			if complex.matchAt(index-1, ancestor, anchor) {
+				return true
+			}
+		}
+
+	case '>':
+		return node._parent != nil && complex.matchAt(index-1, node._parent, anchor)
+
+	case '+':
+		previous := previousElementSibling(node)
+		return previous != nil && complex.matchAt(index-1, previous, anchor)
+
+	case '~':
+		for previous := previousElementSibling(node); previous != nil; previous = previousElementSibling(previous) {
+			if complex.matchAt(index-1, previous, anchor) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+//
+// Check if the node is related to the anchor via the given
+// combinator.
+//
+func isRelated(combinator byte, anchor *HtmlNode, node *HtmlNode) bool {
+	switch combinator {
+	case ' ':
+		for ancestor := node._parent; ancestor != nil; ancestor = ancestor._parent {
+			if ancestor == anchor {
+				return true
+			}
+		}
+
+	case '>':
+		return node._parent == anchor
+
+	case '+':
+		return previousElementSibling(node) == anchor
+
+	case '~':
+		for previous := previousElementSibling(node); previous != nil; previous = previousElementSibling(previous) {
+			if previous == anchor {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+//
+// Check if any of the relative selectors matches a node related
+// to the given anchor node.
+//
+func hasRelative(selectors []*complexSelector, anchor *HtmlNode) bool {
+	for _, complex := range selectors {
+		var candidates []*HtmlNode
+		if complex.leading == ' ' || complex.leading == '>' {
+			candidates = anchor._children
+		} else {
+			candidates = followingSiblings(anchor)
+		}
+
+		if hasRelativeMatch(complex, candidates, anchor) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// Check if the relative selector matches any of the given nodes,
+// or their descendants.
+//
+func hasRelativeMatch(complex *complexSelector, nodes []*HtmlNode, anchor *HtmlNode) bool {
+	for _, node := range nodes {
+		if complex.matchAt(len(complex.compounds)-1, node, anchor) {
+			return true
+		}
+
+		if hasRelativeMatch(complex, node._children, anchor) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// Return the element siblings of the given node, including the
+// node itself. Nodes at the top level are siblings of each other.
+//
+func elementSiblings(node *HtmlNode) []*HtmlNode {
+	var nodes []*HtmlNode
+	if node._parent != nil {
+		nodes = node._parent._children
+	} else if node._wrappingElements != nil {
+		nodes = node._wrappingElements.list().nodes
+	} else {
+		return []*HtmlNode{node}
+	}
+
+	siblings := make([]*HtmlNode, 0, len(nodes))
+	for _, sibling := range nodes {
+		if sibling.NodeType == ElementNode {
+			siblings = append(siblings, sibling)
+		}
+	}
+
+	return siblings
+}
+
+//
+// Return the element sibling right before the given node, if any.
+//
+func previousElementSibling(node *HtmlNode) *HtmlNode {
+	siblings := elementSiblings(node)
+	for index, sibling := range siblings {
+		if sibling == node {
+			if index == 0 {
+				return nil
+			}
+
+			return siblings[index-1]
+		}
+	}
+
+	return nil
+}
+
+//
+// Return all element siblings after the given node.
+//
+func followingSiblings(node *HtmlNode) []*HtmlNode {
+	siblings := elementSiblings(node)
+	for index, sibling := range siblings {
+		if sibling == node {
+			return siblings[index+1:]
+		}
+	}
+
+	return nil
+}
+
+//
+// Return the one-based index of the node among its element
+// siblings, counted from the start or from the end.
+//
+func elementIndex(node *HtmlNode, fromEnd bool) int {
+	siblings := elementSiblings(node)
+	for index, sibling := range siblings {
+		if sibling == node {
+			if fromEnd {
+				return len(siblings) - index
+			}
+
+			return index + 1
+		}
+	}
+
+	return 0
+}
+
+//
+// Check if the value of any attribute with the given name on the
+// node satisfies the given test.
+//
+func matchAttribute(node *HtmlNode, name string, test func(value string) bool) bool {
+	for _, attr := range node.GetAttributes(name) {
+		if test(attr.Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// Check if the whitespace-separated list contains the given word.
+//
+func containsWord(list string, word string) bool {
+	if word == "" {
+		return false
+	}
+
+	for _, field := range strings.Fields(list) {
+		if field == word {
+			return true
+		}
+	}
+
+	return false
+}
+
+//----- parsing
+
+//
+// Parses a CSS selector into its compiled form.
+//
+type selectorParser struct {
+	source string // the selector being parsed
+	index  int    // the current index within the source
+}
+
+//
+// Return an error for the current position.
+//
+func (parser *selectorParser) error(message string) error {
+	return errors.New("Invalid selector '" + parser.source + "' at " + strconv.Itoa(parser.index) + ": " + message)
+}
+
+func (parser *selectorParser) atEnd() bool {
+	return parser.index >= len(parser.source)
+}
+
+func (parser *selectorParser) peek() byte {
+	if parser.atEnd() {
+		return 0
+	}
+
+	return parser.source[parser.index]
+}
+
+//
+// Skip any whitespace, and return `true` if some was skipped.
+//
+func (parser *selectorParser) skipWhitespace() bool {
+	start := parser.index
+	parser.index = skipWhitespace(parser.source, parser.index)
+	return parser.index > start
+}
+
+//
+// Parse a comma-separated list of selectors. The list ends at the
+// end of input or at a closing parenthesis. Relative selectors, as
+// used within `:has()`, may start with a combinator.
+//
+func (parser *selectorParser) parseSelectorList(relative bool) ([]*complexSelector, error) {
+	selectors := make([]*complexSelector, 0)
+	for {
+		parser.skipWhitespace()
+		complex, err := parser.parseComplexSelector(relative)
+		if err != nil {
+			return nil, err
+		}
+
+		selectors = append(selectors, complex)
+		if parser.peek() != ',' {
+			return selectors, nil
+		}
+
+		parser.index++
+	}
+}
+
+//
+// Parse a single selector made of compounds and combinators.
+//
+func (parser *selectorParser) parseComplexSelector(relative bool) (*complexSelector, error) {
+	complex := &complexSelector{
+		compounds:   make([]*compoundSelector, 0),
+		combinators: make([]byte, 0),
+	}
+
+	if relative {
+		complex.leading = ' '
+		if combinator := parser.peek(); combinator == '>' || combinator == '+' || combinator == '~' {
+			complex.leading = combinator
+			parser.index++
+			parser.skipWhitespace()
+		}
+	}
+
+	for {
+		compound, err := parser.parseCompoundSelector()
+		if err != nil {
+			return nil, err
+		}
+		complex.compounds = append(complex.compounds, compound)
+
+		// find the combinator, if any
+		hadWhitespace := parser.skipWhitespace()
+		combinator := parser.peek()
+		switch {
+		case combinator == '>' || combinator == '+' || combinator == '~':
+			parser.index++
+			parser.skipWhitespace()
+
+		case parser.atEnd() || combinator == ',' || combinator == ')':
+			return complex, nil
+
+		case hadWhitespace:
+			combinator = ' '
+
+		default:
+			return nil, parser.error("unexpected character '" + string(combinator) + "'")
+		}
+
+		complex.combinators = append(complex.combinators, combinator)
+	}
+}
+
+//
+// Parse a compound selector such as `div.a#b[c]:first-child`.
+//
+func (parser *selectorParser) parseCompoundSelector() (*compoundSelector, error) {
+	compound := &compoundSelector{
+		matchers: make([]nodeMatcher, 0),
+	}
+
+	// the type selector
+	start := parser.index
+	if parser.peek() == '*' {
+		parser.index++
+	} else if parser.isNameStart() {
+		name, err := parser.parseName()
+		if err != nil {
+			return nil, err
+		}
+		compound.tagName = strings.ToLower(name)
+	}
+
+	for {
+		var matcher nodeMatcher
+		var err error
+
+		switch parser.peek() {
+		case '#':
+			parser.index++
+			matcher, err = parser.parseIdSelector()
+
+		case '.':
+			parser.index++
+			matcher, err = parser.parseClassSelector()
+
+		case '[':
+			parser.index++
+			matcher, err = parser.parseAttributeSelector()
+
+		case ':':
+			parser.index++
+			matcher, err = parser.parsePseudoClass()
+
+		default:
+			if parser.index == start {
+				return nil, parser.error("expected a selector")
+			}
+
+			return compound, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+		compound.matchers = append(compound.matchers, matcher)
+	}
+}
+
+func (parser *selectorParser) parseIdSelector() (nodeMatcher, error) {
+	id, err := parser.parseName()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(node *HtmlNode) bool {
+		return matchAttribute(node, "id", func(value string) bool {
+			return value == id
+		})
+	}, nil
+}
+
+func (parser *selectorParser) parseClassSelector() (nodeMatcher, error) {
+	class, err := parser.parseName()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(node *HtmlNode) bool {
+		return matchAttribute(node, "class", func(value string) bool {
+			return containsWord(value, class)
+		})
+	}, nil
+}
+
+//
+// Parse an attribute selector such as `[a^="b" i]`. The opening
+// bracket has already been consumed.
+//
+func (parser *selectorParser) parseAttributeSelector() (nodeMatcher, error) {
+	parser.skipWhitespace()
+	name, err := parser.parseName()
+	if err != nil {
+		return nil, err
+	}
+	parser.skipWhitespace()
+
+	// just the presence of attribute
+	if parser.peek() == ']' {
+		parser.index++
+		return func(node *HtmlNode) bool {
+			return node.HasAttribute(name)
+		}, nil
+	}
+
+	// the operator
+	operator := ""
+	if parser.peek() == '=' {
+		operator = "="
+	} else if strings.HasPrefix(parser.source[parser.index:], "~=") ||
+		strings.HasPrefix(parser.source[parser.index:], "|=") ||
+		strings.HasPrefix(parser.source[parser.index:], "^=") ||
+		strings.HasPrefix(parser.source[parser.index:], "$=") ||
+		strings.HasPrefix(parser.source[parser.index:], "*=") {
+		operator = parser.source[parser.index : parser.index+2]
+	} else {
+		return nil, parser.error("expected an attribute operator")
+	}
+	parser.index += len(operator)
+	parser.skipWhitespace()
+
+	// the value
+	var expected string
+	if quote := parser.peek(); quote == '"' || quote == '\'' {
+		expected, err = parser.parseString()
+	} else {
+		expected, err = parser.parseName()
+	}
+	if err != nil {
+		return nil, err
+	}
+	parser.skipWhitespace()
+
+	// the case-sensitivity flag
+	ignoreCase := false
+	if flag := parser.peek(); flag == 'i' || flag == 'I' {
+		ignoreCase = true
+		parser.index++
+		parser.skipWhitespace()
+	} else if flag == 's' || flag == 'S' {
+		parser.index++
+		parser.skipWhitespace()
+	}
+
+	if parser.peek() != ']' {
+		return nil, parser.error("expected ']'")
+	}
+	parser.index++
+
+	if ignoreCase {
+		expected = strings.ToLower(expected)
+	}
+
+	test := attributeValueTest(operator, expected)
+	return func(node *HtmlNode) bool {
+		return matchAttribute(node, name, func(value string) bool {
+			if ignoreCase {
+				value = strings.ToLower(value)
+			}
+
+			return test(value)
+		})
+	}, nil
+}
+
+//
+// Return the test for the value of an attribute as per the given
+// operator.
+//
+func attributeValueTest(operator string, expected string) func(value string) bool {
+	switch operator {
+	case "~=":
+		return func(value string) bool {
+			return containsWord(value, expected)
+		}
+
+	case "|=":
+		return func(value string) bool {
+			return value == expected || strings.HasPrefix(value, expected+"-")
+		}
+
+	case "^=":
+		return func(value string) bool {
+			return expected != "" && strings.HasPrefix(value, expected)
+		}
+
+	case "$=":
+		return func(value string) bool {
+			return expected != "" && strings.HasSuffix(value, expected)
+		}
+
+	case "*=":
+		return func(value string) bool {
+			return expected != "" && strings.Contains(value, expected)
+		}
+	}
+
+	return func(value string) bool {
+		return value == expected
+	}
+}
+
+//
+// Parse a pseudo-class such as `:first-child` or `:not(p)`. The
+// colon has already been consumed.
+//
+func (parser *selectorParser) parsePseudoClass() (nodeMatcher, error) {
+	name, err := parser.parseName()
+	if err != nil {
+		return nil, err
+	}
+	name = strings.ToLower(name)
+
+	switch name {
+	case "root":
+		return func(node *HtmlNode) bool {
+			return node._parent == nil
+		}, nil
+
+	case "empty":
+		return func(node *HtmlNode) bool {
+			for _, child := range node._children {
+				if child.NodeType != CommentNode {
+					return false
+				}
+			}
+			return true
+		}, nil
+
+	case "first-child":
+		return func(node *HtmlNode) bool {
+			return elementIndex(node, false) == 1
+		}, nil
+
+	case "last-child":
+		return func(node *HtmlNode) bool {
+			return elementIndex(node, true) == 1
+		}, nil
+
+	case "only-child":
+		return func(node *HtmlNode) bool {
+			return len(elementSiblings(node)) == 1
+		}, nil
+	}
+
+	// functional pseudo-classes
+	if parser.peek() != '(' {
+		return nil, parser.error("unsupported pseudo-class ':" + name + "'")
+	}
+	parser.index++
+	parser.skipWhitespace()
+
+	var matcher nodeMatcher
+	switch name {
+	case "nth-child", "nth-last-child":
+		a, b, err := parser.parseNth()
+		if err != nil {
+			return nil, err
+		}
+
+		fromEnd := name == "nth-last-child"
+		matcher = func(node *HtmlNode) bool {
+			return matchesNth(a, b, elementIndex(node, fromEnd))
+		}
+
+	case "not":
+		selectors, err := parser.parseSelectorList(false)
+		if err != nil {
+			return nil, err
+		}
+
+		matcher = func(node *HtmlNode) bool {
+			return !matchAny(selectors, node)
+		}
+
+	case "has":
+		selectors, err := parser.parseSelectorList(true)
+		if err != nil {
+			return nil, err
+		}
+
+		matcher = func(node *HtmlNode) bool {
+			return hasRelative(selectors, node)
+		}
+
+	default:
+		return nil, parser.error("unsupported pseudo-class ':" + name + "()'")
+	}
+
+	parser.skipWhitespace()
+	if parser.peek() != ')' {
+		return nil, parser.error("expected ')'")
+	}
+	parser.index++
+
+	return matcher, nil
+}
+
+//
+// Parse the `An+B` argument of `:nth-child()`, including the `odd`
+// and `even` keywords.
+//
+func (parser *selectorParser) parseNth() (int, int, error) {
+	start := parser.index
+	for !parser.atEnd() && parser.peek() != ')' {
+		parser.index++
+	}
+
+	expression := strings.ToLower(strings.Join(strings.Fields(parser.source[start:parser.index]), ""))
+	switch expression {
+	case "odd":
+		return 2, 1, nil
+
+	case "even":
+		return 2, 0, nil
+	}
+
+	index := strings.IndexByte(expression, 'n')
+	if index < 0 {
+		b, err := strconv.Atoi(expression)
+		if err != nil {
+			return 0, 0, parser.error("invalid argument '" + expression + "'")
+		}
+
+		return 0, b, nil
+	}
+
+	// the coefficient
+	var a int
+	switch coefficient := expression[:index]; coefficient {
+	case "", "+":
+		a = 1
+
+	case "-":
+		a = -1
+
+	default:
+		value, err := strconv.Atoi(coefficient)
+		if err != nil {
+			return 0, 0, parser.error("invalid argument '" + expression + "'")
+		}
+		a = value
+	}
+
+	// the offset
+	b := 0
+	if offset := expression[index+1:]; offset != "" {
+		if offset[0] != '+' && offset[0] != '-' {
+			return 0, 0, parser.error("invalid argument '" + expression + "'")
+		}
+
+		value, err := strconv.Atoi(offset)
+		if err != nil {
+			return 0, 0, parser.error("invalid argument '" + expression + "'")
+		}
+		b = value
+	}
+
+	return a, b, nil
+}
+
+//
+// Check if the one-based index is of the form `a*n + b` for some
+// non-negative `n`.
+//
+func matchesNth(a int, b int, index int) bool {
+	if index <= 0 {
+		return false
+	}
+
+	if a == 0 {
+		return index == b
+	}
+
+	difference := index - b
+	return difference%a == 0 && difference/a >= 0
+}
+
+//
+// Check if the current character can start a name.
+//
+func (parser *selectorParser) isNameStart() bool {
+	c := parser.peek()
+	return c == '\\' || c == '-' || c == '_' || c >= 0x80 ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+//
+// Parse a name, resolving any escaped characters.
+//
+func (parser *selectorParser) parseName() (string, error) {
+	builder := strings.Builder{}
+	for !parser.atEnd() && parser.isNameStart() {
+		c := parser.peek()
+		if c != '\\' {
+			builder.WriteByte(c)
+			parser.index++
+			continue
+		}
+
+		parser.index++
+		if parser.atEnd() {
+			return "", parser.error("incomplete escape")
+		}
+
+		builder.WriteString(parser.parseEscape())
+	}
+
+	if builder.Len() == 0 {
+		return "", parser.error("expected a name")
+	}
+
+	return builder.String(), nil
+}
+
+//
+// Parse an escaped character, the backslash has already been
+// consumed. This is either up to six hex digits followed by an
+// optional whitespace, or any other single character.
+//
+func (parser *selectorParser) parseEscape() string {
+	start := parser.index
+	for parser.index < len(parser.source) && parser.index-start < 6 && isHexDigit(parser.source[parser.index]) {
+		parser.index++
+	}
+
+	if parser.index == start {
+		parser.index++
+		return parser.source[start:parser.index]
+	}
+
+	code, _ := strconv.ParseUint(parser.source[start:parser.index], 16, 32)
+	if !parser.atEnd() && isWhitespace(parser.peek()) {
+		parser.index++
+	}
+
+	return string(rune(code))
+}
+
+//
+// Parse a quoted string, resolving any escaped characters.
+//
+func (parser *selectorParser) parseString() (string, error) {
+	quote := parser.peek()
+	parser.index++
+
+	builder := strings.Builder{}
+	for !parser.atEnd() {
+		c := parser.peek()
+		switch c {
+		case quote:
+			parser.index++
+			return builder.String(), nil
+
+		case '\\':
+			parser.index++
+			if !parser.atEnd() {
+				builder.WriteString(parser.parseEscape())
+			}
+
+		default:
+			builder.WriteByte(c)
+			parser.index++
+		}
+	}
+
+	return "", parser.error("unterminated string")
+}
+
+//
+// Check if the given byte is a hexadecimal digit.
+//
+func isHexDigit(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
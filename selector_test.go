@@ -0,0 +1,199 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const selectorHtml = `<html>
+<body>
+	<div id="main" class="content wide" lang="en-US">
+		<p class="intro">First</p>
+		<p>Second <a href="https://example.com/page.html" rel="nofollow external">link</a></p>
+		<ul>
+			<li>One</li>
+			<li class="odd">Two</li>
+			<li>Three</li>
+			<li>Four</li>
+			<li>Five</li>
+		</ul>
+		<span></span>
+		<custom:PageBody data-x="1"><b>bold</b></custom:PageBody>
+	</div>
+	<div id="footer"><!-- only a comment --></div>
+</body>
+</html>`
+
+//
+// Return the ids, or text of first child, of all nodes matching
+// the given selector.
+//
+func queryNames(t *testing.T, elements *HtmlElements, selector string) []string {
+	found, err := elements.QueryAll(selector)
+	assert.NoError(t, err)
+
+	names := make([]string, 0)
+	for _, node := range found.Nodes() {
+		name := node.NodeName()
+		if id, err := node.GetAttributeValue("id"); err == nil {
+			name += "#" + id
+		} else if node.HasChildren() && node.First().NodeType == TextNode {
+			name += ":" + strings.TrimSpace(node.First().Data)
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func TestQuerySimpleSelectors(t *testing.T) {
+	doc, err := getDoc(selectorHtml)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"div#main", "div#footer"}, queryNames(t, doc, "div"))
+	assert.Equal(t, []string{"div#main", "div#footer"}, queryNames(t, doc, "DIV"))
+	assert.Equal(t, []string{"div#footer"}, queryNames(t, doc, "#footer"))
+	assert.Equal(t, []string{"p:First"}, queryNames(t, doc, "p.intro"))
+	assert.Equal(t, []string{"div#main"}, queryNames(t, doc, ".content.wide"))
+	assert.Equal(t, []string{}, queryNames(t, doc, ".content.narrow"))
+	assert.Equal(t, []string{"b:bold"}, queryNames(t, doc, "custom\\:PageBody > *"))
+	assert.Equal(t, []string{"b:bold"}, queryNames(t, doc, "custom\\3A pagebody b"))
+}
+
+func TestQueryAttributeSelectors(t *testing.T) {
+	doc, err := getDoc(selectorHtml)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"a:link"}, queryNames(t, doc, "[href]"))
+	assert.Equal(t, []string{"a:link"}, queryNames(t, doc, "a[href^='https://']"))
+	assert.Equal(t, []string{"a:link"}, queryNames(t, doc, "a[href$=\".html\"]"))
+	assert.Equal(t, []string{"a:link"}, queryNames(t, doc, "a[href*=example]"))
+	assert.Equal(t, []string{"a:link"}, queryNames(t, doc, "a[rel~=external]"))
+	assert.Equal(t, []string{}, queryNames(t, doc, "a[rel~=extern]"))
+	assert.Equal(t, []string{"div#main"}, queryNames(t, doc, "[lang|=en]"))
+	assert.Equal(t, []string{"div#main"}, queryNames(t, doc, "[id=MAIN i]"))
+	assert.Equal(t, []string{}, queryNames(t, doc, "[id=MAIN]"))
+	assert.Equal(t, []string{}, queryNames(t, doc, "[href^='']"))
+}
+
+func TestQueryCombinators(t *testing.T) {
+	doc, err := getDoc(selectorHtml)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"a:link"}, queryNames(t, doc, "div a"))
+	assert.Equal(t, []string{}, queryNames(t, doc, "div > a"))
+	assert.Equal(t, []string{"a:link"}, queryNames(t, doc, "div > p > a"))
+	assert.Equal(t, []string{"p:Second"}, queryNames(t, doc, "p.intro + p"))
+	assert.Equal(t, []string{"p:Second", "ul", "span", "custom:pagebody"}, queryNames(t, doc, "p.intro ~ *"))
+	assert.Equal(t, []string{"div#footer"}, queryNames(t, doc, "div + div"))
+	assert.Equal(t, []string{"li:Two"}, queryNames(t, doc, "body div#main ul>li.odd"))
+}
+
+func TestQueryGrouping(t *testing.T) {
+	doc, err := getDoc(selectorHtml)
+	assert.NoError(t, err)
+
+	// nodes are returned in document order, only once
+	assert.Equal(t, []string{"div#main", "p:First", "span", "div#footer"}, queryNames(t, doc, "span, #main, p.intro, div#footer, [id=main]"))
+}
+
+func TestQueryPseudoClasses(t *testing.T) {
+	doc, err := getDoc(selectorHtml)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"li:One"}, queryNames(t, doc, "li:first-child"))
+	assert.Equal(t, []string{"li:Five"}, queryNames(t, doc, "li:last-child"))
+	assert.Equal(t, []string{"html", "body", "a:link", "b:bold"}, queryNames(t, doc, ":only-child"))
+	assert.Equal(t, []string{"li:One", "li:Three", "li:Five"}, queryNames(t, doc, "li:nth-child(odd)"))
+	assert.Equal(t, []string{"li:Two", "li:Four"}, queryNames(t, doc, "li:nth-child(even)"))
+	assert.Equal(t, []string{"li:Two", "li:Five"}, queryNames(t, doc, "li:nth-child(3n + 2)"))
+	assert.Equal(t, []string{"li:One", "li:Two", "li:Three"}, queryNames(t, doc, "li:nth-child(-n+3)"))
+	assert.Equal(t, []string{"li:Three"}, queryNames(t, doc, "li:nth-child(3)"))
+	assert.Equal(t, []string{"li:Four"}, queryNames(t, doc, "li:nth-last-child(2)"))
+	assert.Equal(t, []string{"li:One", "li:Three", "li:Four", "li:Five"}, queryNames(t, doc, "li:not(.odd)"))
+	assert.Equal(t, []string{"span", "div#footer"}, queryNames(t, doc, "body :empty"))
+	assert.Equal(t, []string{"html"}, queryNames(t, doc, ":root"))
+}
+
+func TestQueryHas(t *testing.T) {
+	doc, err := getDoc(selectorHtml)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"html", "body", "div#main", "p:Second"}, queryNames(t, doc, ":has(a)"))
+	assert.Equal(t, []string{"p:Second"}, queryNames(t, doc, "p:has(> a)"))
+	assert.Equal(t, []string{"div#main"}, queryNames(t, doc, "div:has(> ul li.odd)"))
+	assert.Equal(t, []string{"p:First"}, queryNames(t, doc, "p:has(+ p)"))
+	assert.Equal(t, []string{"li:One", "li:Two", "li:Three", "li:Four"}, queryNames(t, doc, "li:has(~ li)"))
+	assert.Equal(t, []string{"div#main"}, queryNames(t, doc, "div:not(:has(> span:empty)) ~ div, div:has(span)"))
+}
+
+func TestNodeQuery(t *testing.T) {
+	doc, err := getDoc(selectorHtml)
+	assert.NoError(t, err)
+
+	main := doc.GetElementById("main")
+	assert.NotNil(t, main)
+
+	// the node itself is never matched
+	found, err := main.QueryAll("div")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, found.Length())
+
+	// but ancestors are considered
+	found, err = main.QueryAll("body p")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, found.Length())
+
+	node, err := main.Query("li:nth-child(2)")
+	assert.NoError(t, err)
+	assert.Equal(t, "Two", node.First().Data)
+
+	node, err = main.Query("table")
+	assert.NoError(t, err)
+	assert.Nil(t, node)
+
+	node, err = doc.Query("p")
+	assert.NoError(t, err)
+	assert.Equal(t, "First", node.First().Data)
+
+	matches, err := main.Matches("div.content")
+	assert.NoError(t, err)
+	assert.True(t, matches)
+}
+
+func TestQueryFragment(t *testing.T) {
+	doc, err := getDoc("<p>one</p><p>two</p>text<p>three</p>")
+	assert.NoError(t, err)
+
+	// top-level nodes are siblings
+	assert.Equal(t, []string{"p:two", "p:three"}, queryNames(t, doc, "p + p"))
+	assert.Equal(t, []string{"p:three"}, queryNames(t, doc, "p:last-child"))
+}
+
+func TestInvalidSelectors(t *testing.T) {
+	doc, err := getDoc(selectorHtml)
+	assert.NoError(t, err)
+
+	invalid := []string{"", "div,", "> p", "p >", "[href", "[href=]", "[href#x]", "a[href='x]",
+		":unknown", ":nth-child(x)", ":nth-child(2n+)", ":not(p", "p:has()", "p{", "#"}
+	for _, selector := range invalid {
+		_, err := doc.QueryAll(selector)
+		assert.Error(t, err, selector)
+
+		_, err = CompileSelector(selector)
+		assert.Error(t, err, selector)
+	}
+}
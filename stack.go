@@ -11,6 +11,10 @@
 
 package lhtml
 
+import (
+	"strings"
+)
+
 //
 // A simple stack to hold our own `HtmlNode` objects.
 //
@@ -82,3 +86,35 @@ func (stack *nodeStack) NumNodes() int {
 
 	return len(stack.elements)
 }
+
+//
+// Find the index of the top-most element on the stack that has
+// the given tag name. Returns `-1` if no such element exists.
+//
+func (stack *nodeStack) lastIndexOf(tagName string) int {
+	for index := len(stack.elements) - 1; index >= 0; index-- {
+		if strings.EqualFold(stack.elements[index]._tagName, tagName) {
+			return index
+		}
+	}
+
+	return -1
+}
+
+//
+// Pop all elements from the stack down to, and including, the
+// element at the given index. Returns the popped elements with
+// the top of the stack first.
+//
+func (stack *nodeStack) popTo(index int) []*HtmlNode {
+	if index < 0 || index >= len(stack.elements) {
+		return nil
+	}
+
+	popped := make([]*HtmlNode, 0, len(stack.elements)-index)
+	for len(stack.elements) > index {
+		popped = append(popped, stack.pop())
+	}
+
+	return popped
+}
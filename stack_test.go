@@ -46,3 +46,22 @@ func TestUninitializedStack(t *testing.T) {
 
 	assert.Equal(t, 0, stack.NumNodes())
 }
+
+func TestStackPopTo(t *testing.T) {
+	a := newNode("a")
+	b := newNode("b")
+	c := newNode("c")
+
+	stack := newNodeStack()
+	stack.push(a)
+	stack.push(b)
+	stack.push(c)
+
+	assert.Equal(t, -1, stack.lastIndexOf("d"))
+	assert.Equal(t, 1, stack.lastIndexOf("B"))
+	assert.Nil(t, stack.popTo(5))
+
+	popped := stack.popTo(1)
+	assert.Equal(t, []*HtmlNode{c, b}, popped)
+	assert.Equal(t, a, stack.peek())
+}
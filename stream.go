@@ -0,0 +1,71 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"io"
+)
+
+//
+// Receives the nodes as they are read by `ParseStream`. The nodes
+// passed are not attached to each other, and are not retained by
+// the parser once the handler returns, except for the elements that
+// are still open.
+//
+// Every `StartElement` is followed by exactly one `EndElement` for
+// the same node, including elements that are void, self-closing or
+// closed implicitly when recovering from bad markup. Thus, the
+// events are always properly nested.
+//
+// Returning an error from any method stops the parsing, and the
+// error is returned to the caller of `ParseStream`.
+//
+type Handler interface {
+	// Called when an element is opened. The node has its name and
+	// attributes, but no children.
+	StartElement(node *HtmlNode) error
+
+	// Called when an element is closed, explicitly or implicitly.
+	EndElement(node *HtmlNode) error
+
+	// Called for text within, or between, elements. This is also
+	// called for template expressions, which have their `NodeType`
+	// set to `ExpressionNode`.
+	Text(node *HtmlNode) error
+
+	// Called for a comment.
+	Comment(node *HtmlNode) error
+
+	// Called for a doctype declaration.
+	Doctype(node *HtmlNode) error
+}
+
+//
+// Parse the markup from the given reader and pass every node to
+// the given handler as soon as it is read, without building the
+// tree in memory. The markup is read with the same lenient rules
+// and options as `ParseWithOptions`, so building a tree from the
+// events gives the same result.
+//
+// Returns the diagnostics for every problem that was encountered,
+// and recovered from, during parsing. An error is returned when the
+// markup could not be read, the options ask to fail on problems, or
+// when the handler returned an error.
+//
+func ParseStream(reader io.Reader, handler Handler, options *ParseOptions) (Diagnostics, error) {
+	parser, err := parse(reader, handler, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return parser.diagnostics, nil
+}
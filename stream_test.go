@@ -0,0 +1,143 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//
+// A handler that records all events as strings.
+//
+type recordingHandler struct {
+	events []string
+	failOn string
+}
+
+func (handler *recordingHandler) record(event string) error {
+	handler.events = append(handler.events, event)
+	if event == handler.failOn {
+		return errors.New("failed on " + event)
+	}
+
+	return nil
+}
+
+func (handler *recordingHandler) StartElement(node *HtmlNode) error {
+	return handler.record("<" + node.NodeName() + ">")
+}
+
+func (handler *recordingHandler) EndElement(node *HtmlNode) error {
+	return handler.record("</" + node.NodeName() + ">")
+}
+
+func (handler *recordingHandler) Text(node *HtmlNode) error {
+	return handler.record(node.Data)
+}
+
+func (handler *recordingHandler) Comment(node *HtmlNode) error {
+	return handler.record("<!--" + node.Data + "-->")
+}
+
+func (handler *recordingHandler) Doctype(node *HtmlNode) error {
+	return handler.record("<!" + node.Data + ">")
+}
+
+func streamEvents(t *testing.T, html string, options *ParseOptions) ([]string, Diagnostics) {
+	handler := &recordingHandler{}
+	diagnostics, err := ParseStream(strings.NewReader(html), handler, options)
+	assert.NoError(t, err)
+	return handler.events, diagnostics
+}
+
+func TestParseStream(t *testing.T) {
+	events, diagnostics := streamEvents(t, "<!DOCTYPE html><html><body a='b'>Hello<br><img/><!-- c --></body></html>", nil)
+	assert.Equal(t, []string{"<!html>", "<html>", "<body>", "Hello", "<br>", "</br>", "<img>", "</img>", "<!-- c -->", "</body>", "</html>"}, events)
+	assert.Equal(t, 0, len(diagnostics))
+}
+
+func TestParseStreamRecovery(t *testing.T) {
+	// implicitly closed elements get an end event
+	events, diagnostics := streamEvents(t, "<div><p><span>text</div></b><i>", nil)
+	assert.Equal(t, []string{"<div>", "<p>", "<span>", "text", "</span>", "</p>", "</div>", "<i>", "</i>"}, events)
+	assert.Equal(t, 2, len(diagnostics.WithCode(ImplicitlyClosedElement)))
+	assert.Equal(t, 1, len(diagnostics.WithCode(StrayEndTag)))
+	assert.Equal(t, 1, len(diagnostics.WithCode(UnclosedElement)))
+
+	// options are honored
+	options := getDefaultOptions()
+	options.EndTagRecovery = IgnoreMismatchedEndTag
+	options.PreserveWhitespace = true
+	events, _ = streamEvents(t, "<div><p> </div></p>", options)
+	assert.Equal(t, []string{"<div>", "<p>", " ", "</p>", "</div>"}, events)
+
+	options.EndTagRecovery = FailOnMismatchedEndTag
+	_, err := ParseStream(strings.NewReader("<div></p>"), &recordingHandler{}, options)
+	assert.Error(t, err)
+}
+
+func TestParseStreamHandlerError(t *testing.T) {
+	handler := &recordingHandler{
+		failOn: "</p>",
+	}
+
+	_, err := ParseStream(strings.NewReader("<div><p>one</p><p>two</p></div>"), handler, nil)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"<div>", "<p>", "one", "</p>"}, handler.events)
+
+	_, err = ParseStream(strings.NewReader("<div>"), nil, nil)
+	assert.Error(t, err)
+
+	_, err = ParseStream(nil, handler, nil)
+	assert.Error(t, err)
+}
+
+func TestParseStreamAgreesWithTree(t *testing.T) {
+	html := "<!DOCTYPE html><html><head><title>T</title></head><body><ul><li>a<li>b</ul><p>x</span>y</p><hr></body>"
+	events, diagnostics := streamEvents(t, html, nil)
+
+	result, err := ParseWithDiagnostics(strings.NewReader(html), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, len(result.Diagnostics), len(diagnostics))
+	for index, diagnostic := range diagnostics {
+		assert.Equal(t, result.Diagnostics[index].String(), diagnostic.String())
+	}
+
+	// replay the tree as events
+	expected := make([]string, 0)
+	var replay func(node *HtmlNode)
+	replay = func(node *HtmlNode) {
+		switch node.NodeType {
+		case ElementNode:
+			expected = append(expected, "<"+node.NodeName()+">")
+			for _, child := range node.Children() {
+				replay(child)
+			}
+			expected = append(expected, "</"+node.NodeName()+">")
+
+		case TextNode:
+			expected = append(expected, node.Data)
+
+		case DoctypeNode:
+			expected = append(expected, "<!"+node.Data+">")
+		}
+	}
+	for _, node := range result.Elements.Nodes() {
+		replay(node)
+	}
+
+	assert.Equal(t, expected, events)
+}
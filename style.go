@@ -0,0 +1,260 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"strings"
+)
+
+//
+// A live view over the inline CSS declarations of a node. The
+// declarations are read from all `style` attributes of the node,
+// in order. Any change writes all declarations back into the first
+// `style` attribute, and removes the other `style` attributes.
+//
+type Style struct {
+	node *HtmlNode // the node whose style is viewed
+}
+
+//
+// A single CSS declaration such as `color: red`.
+//
+type styleDeclaration struct {
+	property string // the property as written
+	value    string // the value, without surrounding whitespace
+}
+
+//
+// Return the inline style of this node.
+//
+func (node *HtmlNode) Style() *Style {
+	return &Style{
+		node: node,
+	}
+}
+
+//
+// Return the value of the given property, or an empty string if the
+// property is not declared. If the property is declared multiple
+// times the last declaration wins, as in CSS. Property names are
+// matched case-insensitively, except for custom properties such as
+// `--main-color`.
+//
+func (style *Style) Get(property string) string {
+	value := ""
+	for _, declaration := range style.declarations() {
+		if stylePropertyMatches(declaration.property, property) {
+			value = declaration.value
+		}
+	}
+
+	return value
+}
+
+//
+// Set the value of the given property, replacing all existing
+// declarations of it. Setting an empty value removes the property.
+//
+func (style *Style) Set(property string, value string) {
+	property = strings.TrimSpace(property)
+	value = strings.TrimSpace(value)
+	if value == "" {
+		style.Remove(property)
+		return
+	}
+
+	declarations := make([]styleDeclaration, 0)
+	replaced := false
+	for _, declaration := range style.declarations() {
+		if !stylePropertyMatches(declaration.property, property) {
+			declarations = append(declarations, declaration)
+			continue
+		}
+
+		// keep the position of the first declaration
+		if !replaced {
+			declarations = append(declarations, styleDeclaration{property, value})
+			replaced = true
+		}
+	}
+
+	if !replaced {
+		declarations = append(declarations, styleDeclaration{property, value})
+	}
+
+	style.update(declarations)
+}
+
+//
+// Remove all declarations of the given property.
+//
+// Returns `true` if the property was declared, `false` otherwise.
+//
+func (style *Style) Remove(property string) bool {
+	declarations := make([]styleDeclaration, 0)
+	removed := false
+	for _, declaration := range style.declarations() {
+		if stylePropertyMatches(declaration.property, property) {
+			removed = true
+			continue
+		}
+		declarations = append(declarations, declaration)
+	}
+
+	if removed {
+		style.update(declarations)
+	}
+
+	return removed
+}
+
+//
+// Return the names of all declared properties, in order, without
+// duplicates. A property declared more than once is returned as it
+// is spelt the first time.
+//
+func (style *Style) Properties() []string {
+	properties := make([]string, 0)
+	for _, declaration := range style.declarations() {
+		declared := false
+		for _, property := range properties {
+			if stylePropertyMatches(property, declaration.property) {
+				declared = true
+				break
+			}
+		}
+
+		if !declared {
+			properties = append(properties, declaration.property)
+		}
+	}
+
+	return properties
+}
+
+//
+// Return the declarations as they would be written in the `style`
+// attribute.
+//
+func (style *Style) String() string {
+	return formatStyleDeclarations(style.declarations())
+}
+
+//
+// Read all declarations from the `style` attributes of the node.
+//
+func (style *Style) declarations() []styleDeclaration {
+	declarations := make([]styleDeclaration, 0)
+	for _, attr := range style.node.GetAttributes("style") {
+		declarations = append(declarations, parseStyleDeclarations(attr.Value)...)
+	}
+
+	return declarations
+}
+
+//
+// Write the given declarations back to the node.
+//
+func (style *Style) update(declarations []styleDeclaration) {
+	if len(declarations) == 0 && !style.node.HasAttribute("style") {
+		return
+	}
+
+	style.node.mergeAttributes("style", formatStyleDeclarations(declarations))
+}
+
+//
+// Parse the declarations from the given inline style. Semicolons
+// and colons within quotes or parentheses, such as in `url(a;b)`,
+// do not split declarations. Malformed declarations are skipped.
+//
+func parseStyleDeclarations(text string) []styleDeclaration {
+	declarations := make([]styleDeclaration, 0)
+
+	start := 0
+	depth := 0
+	var quote byte
+	for index := 0; index <= len(text); index++ {
+		if index < len(text) {
+			c := text[index]
+			switch {
+			case quote != 0:
+				if c == '\\' && index+1 < len(text) {
+					index++
+				} else if c == quote {
+					quote = 0
+				}
+				continue
+
+			case c == '"' || c == '\'':
+				quote = c
+				continue
+
+			case c == '(':
+				depth++
+				continue
+
+			case c == ')':
+				if depth > 0 {
+					depth--
+				}
+				continue
+
+			case c != ';' || depth > 0:
+				continue
+			}
+		}
+
+		// end of a declaration
+		declaration := text[start:index]
+		start = index + 1
+
+		colon := strings.IndexByte(declaration, ':')
+		if colon < 0 {
+			continue
+		}
+
+		property := strings.TrimSpace(declaration[:colon])
+		value := strings.TrimSpace(declaration[colon+1:])
+		if property == "" || value == "" {
+			continue
+		}
+
+		declarations = append(declarations, styleDeclaration{property, value})
+	}
+
+	return declarations
+}
+
+//
+// Format the given declarations as an inline style.
+//
+func formatStyleDeclarations(declarations []styleDeclaration) string {
+	parts := make([]string, 0, len(declarations))
+	for _, declaration := range declarations {
+		parts = append(parts, declaration.property+": "+declaration.value)
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+//
+// Check if the given CSS property names are the same.
+//
+func stylePropertyMatches(name string, property string) bool {
+	property = strings.TrimSpace(property)
+	if strings.HasPrefix(name, "--") {
+		return name == property
+	}
+
+	return strings.EqualFold(name, property)
+}
@@ -0,0 +1,76 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStyle(t *testing.T) {
+	div := parseWithAttributeOptions(t, `<div style="color: red; background: url('a;b.png') ; ; bad" Style="COLOR:blue;--Main: 1px;--main: 2px"></div>`, false, true, FirstAttributeWins)
+	style := div.Style()
+	assert.Equal(t, "blue", style.Get("color"))
+	assert.Equal(t, "url('a;b.png')", style.Get("Background"))
+	assert.Equal(t, "1px", style.Get("--Main"))
+	assert.Equal(t, "2px", style.Get("--main"))
+	assert.Equal(t, "", style.Get("margin"))
+	assert.Equal(t, []string{"color", "background", "--Main", "--main"}, style.Properties())
+
+	// changes merge duplicate attributes into the first one
+	style.Set("color", "green")
+	assert.Equal(t, `<div style="color: green; background: url('a;b.png'); --Main: 1px; --main: 2px"></div>`, div.String())
+
+	style.Set("margin", " 0 auto ")
+	assert.Equal(t, "color: green; background: url('a;b.png'); --Main: 1px; --main: 2px; margin: 0 auto", style.String())
+
+	assert.True(t, style.Remove("background"))
+	assert.False(t, style.Remove("padding"))
+	style.Set("--Main", "")
+	style.Set("--main", "")
+	assert.Equal(t, "color: green; margin: 0 auto", div.GetAttribute("style").Value)
+	assert.Equal(t, 1, div.NumAttributes())
+}
+
+func TestStyleWithoutAttribute(t *testing.T) {
+	node := NewElement("p")
+	style := node.Style()
+	assert.Equal(t, "", style.Get("color"))
+	assert.False(t, style.Remove("color"))
+	assert.False(t, node.HasAttribute("style"))
+
+	style.Set("color", "red")
+	assert.Equal(t, "<p style=\"color: red\"></p>", node.String())
+}
+
+func TestParseStyleDeclarations(t *testing.T) {
+	declarations := parseStyleDeclarations(`a: 1; b: "x;y" ; c:url(x;y);d:'\';'; :e; f:; g`)
+	assert.Equal(t, []styleDeclaration{
+		{"a", "1"},
+		{"b", `"x;y"`},
+		{"c", "url(x;y)"},
+		{"d", `'\';'`},
+	}, declarations)
+}
+
+func TestParseStyleDeclarationsWithTrailingEscape(t *testing.T) {
+	declarations := parseStyleDeclarations(`a: 1; content: "x\`)
+	assert.Equal(t, []styleDeclaration{
+		{"a", "1"},
+		{"content", `"x\`},
+	}, declarations)
+
+	node := NewElement("p")
+	node.SetAttribute("style", `color: red; font-family: 'a\`)
+	assert.Equal(t, `'a\`, node.Style().Get("font-family"))
+}
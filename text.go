@@ -0,0 +1,275 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"strings"
+)
+
+//
+// Options that control how the text of a node is extracted.
+//
+type TextOptions struct {
+	// Replace every run of whitespace with a single space, and
+	// trim whitespace at both ends of the text.
+	CollapseWhitespace bool
+
+	// Skip the content of `script` and `style` elements.
+	SkipScriptAndStyle bool
+}
+
+//
+// Elements that are laid out as blocks, and thus start and end
+// on a line of their own in `InnerText()`.
+//
+var blockElements = toNameSet([]string{
+	"address", "article", "aside", "blockquote", "body", "caption", "dd", "details",
+	"dialog", "div", "dl", "dt", "fieldset", "figcaption", "figure", "footer", "form",
+	"h1", "h2", "h3", "h4", "h5", "h6", "header", "hgroup", "hr", "html", "li", "main",
+	"nav", "ol", "p", "pre", "section", "summary", "table", "tbody", "tfoot", "thead",
+	"tr", "ul",
+})
+
+//
+// Elements whose content is never part of `InnerText()`.
+//
+var hiddenElements = toNameSet([]string{
+	"head", "script", "style", "template",
+})
+
+//
+// Return the text of this node and all its descendants, in
+// document order, without any changes. Comments are not part of
+// the text.
+//
+func (node *HtmlNode) Text() string {
+	return node.TextWithOptions(TextOptions{})
+}
+
+//
+// Return the text of this node and all its descendants, in
+// document order, as per the given options. Comments are not part
+// of the text, while template expressions are included along with
+// their delimiters.
+//
+func (node *HtmlNode) TextWithOptions(options TextOptions) string {
+	builder := strings.Builder{}
+	node.collectText(&builder, options)
+
+	if options.CollapseWhitespace {
+		return strings.Join(strings.Fields(builder.String()), " ")
+	}
+
+	return builder.String()
+}
+
+//
+// Replace all children of this element with a single text node
+// with the given text. If the text is empty, all children are
+// removed. For other nodes, the data of the node is replaced.
+//
+func (node *HtmlNode) SetText(text string) {
+	if node.NodeType != ElementNode {
+		node.Data = text
+		return
+	}
+
+	node.RemoveAllChildren()
+	if text == "" {
+		return
+	}
+
+	child := NewText(text)
+	child._options = node._options
+	node.InsertChildAt(0, child)
+}
+
+//
+// Return the text of this node as it would be rendered, similar
+// to `innerText` in browsers: whitespace is collapsed, except in
+// `pre` elements, block elements start on a new line, paragraphs
+// are separated by a blank line, `br` starts a new line and table
+// cells are separated by tabs. The content of `head`, `script`,
+// `style` and `template` elements is skipped.
+//
+func (node *HtmlNode) InnerText() string {
+	writer := &innerTextWriter{}
+	writer.write(node, false)
+	return string(writer.text)
+}
+
+//
+// Append the text of this node and its descendants to the builder.
+//
+func (node *HtmlNode) collectText(builder *strings.Builder, options TextOptions) {
+	switch node.NodeType {
+	case TextNode:
+		builder.WriteString(node.Data)
+
+	case ExpressionNode:
+		builder.WriteString(node.ExpressionSource())
+
+	case ElementNode:
+		if options.SkipScriptAndStyle && (node._tagName == "script" || node._tagName == "style") {
+			return
+		}
+
+		for _, child := range node._children {
+			child.collectText(builder, options)
+		}
+	}
+}
+
+//
+// Holds the state when writing the inner text of a node.
+//
+type innerTextWriter struct {
+	text   []byte // the text written so far
+	breaks int    // the number of line breaks required before the next text
+	space  bool   // whether collapsed whitespace is pending before the next text
+}
+
+//
+// Require at least the given number of line breaks before the next
+// text is written. Line breaks are never written at the start or
+// end of the text.
+//
+func (writer *innerTextWriter) requireBreaks(count int) {
+	if count > writer.breaks {
+		writer.breaks = count
+	}
+}
+
+//
+// Write the given text, after any required line breaks or pending
+// whitespace. Whitespace is never written at the start of a line.
+//
+func (writer *innerTextWriter) append(text string) {
+	if text == "" {
+		return
+	}
+
+	length := len(writer.text)
+	if writer.breaks > 0 && length > 0 {
+		writer.text = append(writer.text, strings.Repeat("\n", writer.breaks)...)
+	} else if writer.space && length > 0 && writer.text[length-1] != '\n' {
+		writer.text = append(writer.text, ' ')
+	}
+
+	writer.breaks = 0
+	writer.space = false
+	writer.text = append(writer.text, text...)
+}
+
+//
+// Write the inner text of the given node. The `preformatted` flag
+// indicates that the node is within a `pre` element.
+//
+func (writer *innerTextWriter) write(node *HtmlNode, preformatted bool) {
+	switch node.NodeType {
+	case TextNode:
+		if preformatted {
+			writer.append(node.Data)
+			return
+		}
+
+		// leading and trailing whitespace is kept pending, so that
+		// it is dropped at line breaks
+		text := collapseWhitespace(node.Data)
+		if strings.HasPrefix(text, " ") {
+			writer.space = true
+		}
+		trailing := strings.HasSuffix(text, " ")
+
+		text = strings.Trim(text, " ")
+		if text == "" {
+			return
+		}
+
+		writer.append(text)
+		writer.space = trailing
+
+	case ElementNode:
+		writer.writeElement(node, preformatted)
+	}
+}
+
+//
+// Write the inner text of the given element. Hidden elements are
+// skipped, a `br` adds a line break, and the text within a `pre`
+// is written as is. Block elements are separated from the text
+// around them by a line break, paragraphs by two, and table cells
+// after the first in a row are separated by a tab.
+//
+func (writer *innerTextWriter) writeElement(node *HtmlNode, preformatted bool) {
+	name := node._tagName
+	if hiddenElements[name] {
+		return
+	}
+
+	switch name {
+	case "br":
+		writer.space = false
+		writer.breaks++
+		return
+
+	case "pre":
+		preformatted = true
+
+	case "td", "th":
+		if previousElementSibling(node) != nil && writer.breaks == 0 {
+			writer.space = false
+			writer.append("\t")
+		}
+	}
+
+	breaks := 0
+	if blockElements[name] {
+		breaks = 1
+	}
+	if name == "p" {
+		breaks = 2
+	}
+
+	writer.requireBreaks(breaks)
+	for _, child := range node._children {
+		writer.write(child, preformatted)
+	}
+	writer.requireBreaks(breaks)
+}
+
+//
+// Replace every run of whitespace in the given text with a single
+// space.
+//
+func collapseWhitespace(text string) string {
+	builder := strings.Builder{}
+	space := false
+	for index := 0; index < len(text); index++ {
+		if isWhitespace(text[index]) {
+			space = true
+			continue
+		}
+
+		if space {
+			builder.WriteByte(' ')
+			space = false
+		}
+		builder.WriteByte(text[index])
+	}
+
+	if space {
+		builder.WriteByte(' ')
+	}
+
+	return builder.String()
+}
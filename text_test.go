@@ -0,0 +1,100 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestText(t *testing.T) {
+	doc, err := getDoc("<div>\n  <h1>Title</h1>\n  <p>Hello <b>big</b>  world<!-- hidden --></p>\n  <script>var x = 1;</script><style>p {}</style>\n</div>")
+	assert.NoError(t, err)
+
+	div := doc.First()
+	assert.Equal(t, "TitleHello big  worldvar x = 1;p {}", div.Text())
+	assert.Equal(t, "TitleHello big worldvar x = 1;p {}", div.TextWithOptions(TextOptions{CollapseWhitespace: true}))
+	assert.Equal(t, "TitleHello big  world", div.TextWithOptions(TextOptions{SkipScriptAndStyle: true}))
+
+	// whitespace-only text is kept when parsing with the option
+	options := getDefaultOptions()
+	options.PreserveWhitespace = true
+	doc, err = ParseWithOptions(strings.NewReader("<p>\n  a  <b> b </b>\n</p>"), options)
+	assert.NoError(t, err)
+	assert.Equal(t, "\n  a   b \n", doc.First().Text())
+	assert.Equal(t, "a b", doc.First().TextWithOptions(TextOptions{CollapseWhitespace: true}))
+
+	// text and comment nodes
+	assert.Equal(t, "x", NewText("x").Text())
+	assert.Equal(t, "", NewComment("x").Text())
+}
+
+func TestSetText(t *testing.T) {
+	doc, err := getDoc("<div><p>old</p><b>more</b></div>")
+	assert.NoError(t, err)
+
+	div := doc.First()
+	p := div.First()
+	div.SetText("a < b")
+	assert.Equal(t, "<div>a &lt; b</div>", div.String())
+	assert.Equal(t, "a < b", div.Text())
+	assert.Nil(t, p.Parent())
+	assert.NoError(t, doc.checkInvariants())
+
+	div.SetText("")
+	assert.False(t, div.HasChildren())
+
+	text := NewText("x")
+	text.SetText("y")
+	assert.Equal(t, "y", text.Data)
+}
+
+func TestInnerText(t *testing.T) {
+	html := `<html><head><title>Ignored</title></head><body>
+	<h1>Title</h1>
+	<p>First   paragraph
+	with <b>bold</b> text.</p>
+	<p>Second<br>line <br> break</p>
+	<ul><li>One</li><li>Two</li></ul>
+	<div>Inline <span>span</span><div>nested</div>after</div>
+	<pre>  keep
+   this  </pre>
+	<table><tr><td>a</td><td>b</td></tr><tr><th>c</th> <td>d</td></tr></table>
+	<script>ignored()</script>
+	</body></html>`
+
+	doc, err := getDoc(html)
+	assert.NoError(t, err)
+
+	expected := "Title\n\n" +
+		"First paragraph with bold text.\n\n" +
+		"Second\nline\nbreak\n\n" +
+		"One\nTwo\n" +
+		"Inline span\nnested\nafter\n" +
+		"  keep\n   this  \n" +
+		"a\tb\nc\td"
+
+	assert.Equal(t, expected, doc.First().InnerText())
+	assert.Equal(t, "bold", doc.GetElementsByName("b").First().InnerText())
+	assert.Equal(t, "", NewElement("div").InnerText())
+}
+
+func TestInnerTextLineBreaks(t *testing.T) {
+	doc, err := getDoc("<p><br>x<br><br>y<br></p><div>a<br><div>b</div></div>")
+	assert.NoError(t, err)
+
+	// line breaks at the edges are trimmed
+	assert.Equal(t, "x\n\ny", doc.First().InnerText())
+	assert.Equal(t, "a\nb", doc.Last().InnerText())
+}
@@ -0,0 +1,290 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+//
+// Defines the contract for a node transformer. The transformer
+// receives a node and returns the nodes that replace it, along
+// with `true` if the node is to be replaced. Returning `false`
+// keeps the node as is, and continues into its children.
+//
+// To remove a node return no nodes and `true`. The node itself
+// may be part of its replacement, such as when wrapping it with
+// other nodes, in which case its children are still transformed.
+//
+type HtmlNodeTransformer func(node *HtmlNode, context *WalkContext) (replacement []*HtmlNode, replace bool)
+
+//
+// Options that control how the tree is transformed.
+//
+type TransformOptions struct {
+	// Transform the nodes that replaced a node as well, along with
+	// their children. Otherwise the replacement nodes are kept as
+	// they are. The transformer must make sure it does not keep on
+	// replacing nodes endlessly.
+	RevisitReplacements bool
+}
+
+//
+// Transform all nodes within this list of elements, and their
+// children, using the given transformer. The nodes are visited in
+// document order, and the tree may be modified safely while it is
+// being transformed.
+//
+func (elements *HtmlElements) Transform(transformer HtmlNodeTransformer, options TransformOptions) {
+	if transformer == nil {
+		return
+	}
+
+	transform := newTransform(transformer, options)
+	transform.transformNodes(nil, elements)
+}
+
+//
+// Transform all nodes within the children of this node using the
+// given transformer. This node itself is not transformed. The nodes
+// are visited in document order, and the tree may be modified
+// safely while it is being transformed.
+//
+func (node *HtmlNode) Transform(transformer HtmlNodeTransformer, options TransformOptions) {
+	if transformer == nil {
+		return
+	}
+
+	transform := newTransform(transformer, options)
+	transform.transformChildren(node)
+}
+
+//
+// Holds the state when transforming a tree.
+//
+type transform struct {
+	transformer HtmlNodeTransformer
+	options     TransformOptions
+	context     *WalkContext
+	kept        map[*HtmlNode]bool // nodes that are part of their own replacement
+}
+
+func newTransform(transformer HtmlNodeTransformer, options TransformOptions) *transform {
+	return &transform{
+		transformer: transformer,
+		options:     options,
+		context: &WalkContext{
+			Ancestors: make([]*HtmlNode, 0),
+		},
+		kept: make(map[*HtmlNode]bool),
+	}
+}
+
+//
+// Transform the children of the given node.
+//
+func (transform *transform) transformChildren(node *HtmlNode) {
+	if !node.HasChildren() {
+		return
+	}
+
+	context := transform.context
+	context.Ancestors = append(context.Ancestors, node)
+	context.Depth++
+
+	transform.transformNodes(node, nil)
+
+	context.Ancestors = context.Ancestors[:len(context.Ancestors)-1]
+	context.Depth--
+}
+
+//
+// Transform the children of the given parent, or the nodes of the
+// given elements if there is no parent.
+//
+func (transform *transform) transformNodes(parent *HtmlNode, elements *HtmlElements) {
+	siblings := nodeList(parent, elements)
+	for index := 0; index < len(siblings()); {
+		node := siblings()[index]
+		following := followingNode(siblings(), index)
+
+		var replacement []*HtmlNode
+		replace := false
+		if transform.kept[node] {
+			delete(transform.kept, node)
+		} else {
+			replacement, replace = transform.transformer(node, transform.context)
+		}
+
+		if !replace {
+			transform.transformChildren(node)
+			index = nextIndex(siblings(), index, node, following)
+			continue
+		}
+
+		start, replacement := replaceNode(node, replacement, parent, elements)
+		if start < 0 {
+			// the transformer moved the node elsewhere
+			index = nextIndex(siblings(), index, node, following)
+			continue
+		}
+
+		if transform.options.RevisitReplacements {
+			if containsNode(replacement, node) {
+				transform.kept[node] = true
+			}
+
+			index = start
+			continue
+		}
+
+		if containsNode(replacement, node) {
+			transform.transformChildren(node)
+		}
+
+		index = start + len(replacement)
+	}
+}
+
+//
+// Return a function that returns the current children of the given
+// parent, or the nodes of the given elements if there is no parent.
+//
+func nodeList(parent *HtmlNode, elements *HtmlElements) func() []*HtmlNode {
+	if parent != nil {
+		return func() []*HtmlNode {
+			return parent._children
+		}
+	}
+
+	return func() []*HtmlNode {
+		return elements.list().nodes
+	}
+}
+
+//
+// Replace the given node with the replacement nodes in the children
+// of the given parent, or the nodes of the given elements if there
+// is no parent. Replacement nodes are detached from where they were
+// before. Nodes that would create a cycle are ignored.
+//
+// Returns the index of the first replacement node along with the
+// nodes that were actually inserted, or `-1` if the node is no more
+// a child of the parent.
+//
+func replaceNode(node *HtmlNode, replacement []*HtmlNode, parent *HtmlNode, elements *HtmlElements) (int, []*HtmlNode) {
+	inserted := make([]*HtmlNode, 0, len(replacement))
+	for _, additional := range replacement {
+		if additional == nil || containsNode(inserted, additional) || additional.isAncestorOf(parent) {
+			continue
+		}
+
+		if additional != node {
+			additional.RemoveMe()
+		}
+
+		inserted = append(inserted, additional)
+	}
+
+	siblings := nodeList(parent, elements)()
+	index := indexOfNode(siblings, node, 0)
+	if index < 0 {
+		return -1, nil
+	}
+
+	updated := make([]*HtmlNode, 0, len(siblings)+len(inserted)-1)
+	updated = append(updated, siblings[:index]...)
+	updated = append(updated, inserted...)
+	updated = append(updated, siblings[index+1:]...)
+	if parent != nil {
+		parent._children = updated
+	} else {
+		elements.list().nodes = updated
+	}
+
+	if !containsNode(inserted, node) {
+		node.detach()
+	}
+
+	for _, additional := range inserted {
+		additional._parent = parent
+		additional._wrappingElements = nil
+		if parent == nil {
+			additional._wrappingElements = elements
+		}
+	}
+
+	return index, inserted
+}
+
+//
+// Return the index to continue at after the given node, that was at
+// the given index and followed by the given node, was visited. The
+// node may have been moved, removed or replaced while it was visited.
+// Nodes that were put in place of the visited node replaced it, and
+// are skipped.
+//
+func nextIndex(nodes []*HtmlNode, index int, visited *HtmlNode, following *HtmlNode) int {
+	found := indexOfNode(nodes, visited, index)
+	if found >= 0 {
+		return found + 1
+	}
+
+	if following != nil {
+		found = indexOfNode(nodes, following, index)
+		if found >= 0 {
+			return found
+		}
+	}
+
+	if following == nil && index < len(nodes) {
+		// the node was the last one and was replaced
+		return len(nodes)
+	}
+
+	// the node was removed, and the next node is now
+	// at the same index
+	return index
+}
+
+//
+// Return the node that follows the node at the given index in the
+// list, or `nil` if it is the last one.
+//
+func followingNode(nodes []*HtmlNode, index int) *HtmlNode {
+	if index+1 < len(nodes) {
+		return nodes[index+1]
+	}
+
+	return nil
+}
+
+//
+// Return the index of the given node in the list, looking at the
+// given index first. Returns `-1` if the node is not in the list.
+//
+func indexOfNode(nodes []*HtmlNode, node *HtmlNode, hint int) int {
+	if hint >= 0 && hint < len(nodes) && nodes[hint] == node {
+		return hint
+	}
+
+	for index, candidate := range nodes {
+		if candidate == node {
+			return index
+		}
+	}
+
+	return -1
+}
+
+//
+// Check if the given list of nodes contains the node.
+//
+func containsNode(nodes []*HtmlNode, node *HtmlNode) bool {
+	return indexOfNode(nodes, node, 0) >= 0
+}
@@ -0,0 +1,149 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func textNode(text string) *HtmlNode {
+	return &HtmlNode{
+		NodeType: TextNode,
+		Data:     text,
+	}
+}
+
+func transformedString(t *testing.T, html string, transformer HtmlNodeTransformer, options TransformOptions) string {
+	doc, err := ParseHtmlString(html)
+	assert.NoError(t, err)
+
+	doc.Transform(transformer, options)
+	s, err := doc.String()
+	assert.NoError(t, err)
+	return s
+}
+
+func TestTransformRemove(t *testing.T) {
+	s := transformedString(t, "<div><x:a>1</x:a><p>2</p><x:a>3</x:a></div><x:a>4</x:a>", func(node *HtmlNode, context *WalkContext) ([]*HtmlNode, bool) {
+		return nil, node.NodeName() == "x:a"
+	}, TransformOptions{})
+
+	assert.Equal(t, "<div><p>2</p></div>", s)
+}
+
+func TestTransformReplace(t *testing.T) {
+	visited := make([]string, 0)
+	s := transformedString(t, "<div><x:name>ignored</x:name><p>2</p></div>", func(node *HtmlNode, context *WalkContext) ([]*HtmlNode, bool) {
+		visited = append(visited, node.NodeName()+node.Data)
+		if node.NodeName() == "x:name" {
+			bold := NewElement("b")
+			bold.InsertChildAt(0, textNode("John"))
+			return []*HtmlNode{textNode("Hello "), bold, textNode("!")}, true
+		}
+		return nil, false
+	}, TransformOptions{})
+
+	assert.Equal(t, "<div>Hello <b>John</b>!<p>2</p></div>", s)
+
+	// replacements are not visited
+	assert.Equal(t, []string{"div", "x:name", "p", "2"}, visited)
+}
+
+func TestTransformRevisitReplacements(t *testing.T) {
+	visited := make([]string, 0)
+	s := transformedString(t, "<x:outer>a</x:outer>", func(node *HtmlNode, context *WalkContext) ([]*HtmlNode, bool) {
+		visited = append(visited, node.NodeName()+node.Data)
+		switch node.NodeName() {
+		case "x:outer":
+			inner := NewElement("x:inner")
+			inner._children = node._children
+			return []*HtmlNode{inner}, true
+
+		case "x:inner":
+			span := NewElement("span")
+			span._children = node._children
+			return []*HtmlNode{span}, true
+		}
+		return nil, false
+	}, TransformOptions{RevisitReplacements: true})
+
+	assert.Equal(t, "<span>a</span>", s)
+	assert.Equal(t, []string{"x:outer", "x:inner", "span", "a"}, visited)
+}
+
+func TestTransformWrapSelf(t *testing.T) {
+	for _, revisit := range []bool{false, true} {
+		visited := make([]string, 0)
+		s := transformedString(t, "<div><p>text</p></div>", func(node *HtmlNode, context *WalkContext) ([]*HtmlNode, bool) {
+			visited = append(visited, node.NodeName()+node.Data)
+			if node.NodeName() == "p" {
+				return []*HtmlNode{textNode("["), node, textNode("]")}, true
+			}
+			return nil, false
+		}, TransformOptions{RevisitReplacements: revisit})
+
+		// the node is transformed only once, but its children are visited
+		assert.Equal(t, "<div>[<p>text</p>]</div>", s)
+		if revisit {
+			assert.Equal(t, []string{"div", "p", "[", "text", "]"}, visited)
+		} else {
+			assert.Equal(t, []string{"div", "p", "text"}, visited)
+		}
+	}
+}
+
+func TestTransformLinks(t *testing.T) {
+	doc, err := ParseHtmlString("<div><p>a</p><p>b</p></div><span></span>")
+	assert.NoError(t, err)
+
+	div := doc.First()
+	moved := doc.Get(1)
+
+	// move the top-level span in place of the first paragraph
+	doc.Transform(func(node *HtmlNode, context *WalkContext) ([]*HtmlNode, bool) {
+		if node.NodeName() == "p" && node.First().Data == "a" {
+			return []*HtmlNode{moved, div}, true
+		}
+		return nil, false
+	}, TransformOptions{})
+
+	s, err := doc.String()
+	assert.NoError(t, err)
+
+	// the ancestor is ignored to avoid a cycle
+	assert.Equal(t, "<div><span></span><p>b</p></div>", s)
+	assert.Equal(t, 1, doc.Length())
+	assert.Equal(t, div, moved.Parent())
+	assert.Nil(t, moved._wrappingElements)
+	assert.NoError(t, doc.checkInvariants())
+}
+
+func TestTransformContext(t *testing.T) {
+	doc, err := ParseHtmlString("<div><p><b>x</b></p></div>")
+	assert.NoError(t, err)
+
+	depths := make([]int, 0)
+	doc.First().Transform(func(node *HtmlNode, context *WalkContext) ([]*HtmlNode, bool) {
+		depths = append(depths, context.Depth)
+		assert.Equal(t, node.Parent(), context.Parent())
+		return nil, false
+	}, TransformOptions{})
+
+	// the node itself is not transformed
+	assert.Equal(t, []int{1, 2, 3}, depths)
+
+	// nil transformer does nothing
+	doc.Transform(nil, TransformOptions{})
+	doc.First().Transform(nil, TransformOptions{})
+}
@@ -0,0 +1,68 @@
+/**
+ * lhtml - Lenient HTML parser for Go.
+ *
+ * MIT License.
+ * Copyright (c) 2022, Sandeep Gupta.
+ * https://github.com/sangupta/lhtml
+ *
+ * Use of this source code is governed by a MIT style license
+ * that can be found in LICENSE file in the code repository:
+ */
+
+package lhtml
+
+//
+// The `Handler` that builds the tree of nodes from the parsed
+// events.
+//
+type treeBuilder struct {
+	document *HtmlElements // the elements being built
+	stack    *nodeStack    // the open elements
+	options  *ParseOptions // the options in use
+}
+
+//
+// Create a new tree builder for the given options.
+//
+func newTreeBuilder(options *ParseOptions) *treeBuilder {
+	return &treeBuilder{
+		document: NewHtmlElements(),
+		stack:    newNodeStack(),
+		options:  options,
+	}
+}
+
+func (builder *treeBuilder) StartElement(node *HtmlNode) error {
+	builder.document.addNodeToStack(node, builder.stack)
+	return nil
+}
+
+func (builder *treeBuilder) EndElement(node *HtmlNode) error {
+	builder.stack.pop()
+	return nil
+}
+
+func (builder *treeBuilder) Text(node *HtmlNode) error {
+	builder.document.addNodeToStack(node, builder.stack)
+	builder.stack.pop()
+	return nil
+}
+
+func (builder *treeBuilder) Comment(node *HtmlNode) error {
+	// older versions added all comments at the top level
+	if builder.options.FlatComments {
+		builder.document.appendNode(node)
+		return nil
+	}
+
+	builder.document.addNodeToStack(node, builder.stack)
+	builder.stack.pop()
+	return nil
+}
+
+func (builder *treeBuilder) Doctype(node *HtmlNode) error {
+	// keep the doctype where it appears in source
+	builder.document.addNodeToStack(node, builder.stack)
+	builder.stack.pop()
+	return nil
+}
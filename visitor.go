@@ -20,49 +20,193 @@ package lhtml
 type HtmlNodeVisitor func(node *HtmlNode) bool
 
 //
-// Allow traversing over the `HtmlDocument`. If a `nil`
-// visitor is supplied, no tree traversal happens.
+// The action returned by a `HtmlNodeWalker` to control how the
+// walk continues.
 //
-func (doc *HtmlElements) Traverse(visitor HtmlNodeVisitor) {
-	if visitor == nil {
-		return
+type WalkAction uint32
+
+// Enumeration
+const (
+	// Continue walking the tree, including the children of the
+	// node just entered.
+	WalkContinue WalkAction = iota
+
+	// Continue walking the tree, but skip the children of the node
+	// just entered. The node is still left.
+	WalkSkipChildren
+
+	// Stop walking the tree immediately.
+	WalkStop
+)
+
+//
+// Describes where in the tree a walker currently is.
+//
+type WalkContext struct {
+	// The depth of the node relative to where the walk started.
+	// The nodes the walk starts at have a depth of zero.
+	Depth int
+
+	// The ancestors of the node, from where the walk started down
+	// to the parent of the node. The slice is reused during the
+	// walk, so it must be copied if it needs to be retained.
+	Ancestors []*HtmlNode
+}
+
+//
+// Return the parent of the node within the walk, or `nil` for the
+// nodes where the walk started.
+//
+func (context *WalkContext) Parent() *HtmlNode {
+	if len(context.Ancestors) == 0 {
+		return nil
 	}
 
-	if doc.IsEmpty() {
-		return
+	return context.Ancestors[len(context.Ancestors)-1]
+}
+
+//
+// A visitor that is called when a node is entered, before any of
+// its children, and when it is left, after all of its children.
+// Either of the functions may be `nil`.
+//
+type HtmlNodeWalker struct {
+	// Called before the children of a node are walked.
+	Enter func(node *HtmlNode, context *WalkContext) WalkAction
+
+	// Called after the children of a node were walked, even if
+	// they were skipped. Not called if entering the node stopped
+	// the walk.
+	Leave func(node *HtmlNode, context *WalkContext) WalkAction
+}
+
+//
+// Walk over all nodes in this list of elements, and their children,
+// using the given walker. The walker may modify the tree: nodes that
+// are removed before they are reached are not walked, while nodes
+// inserted after the current node are. When the current node is
+// removed or replaced, its children and its replacement are not
+// walked.
+//
+// Returns `false` if the walker stopped the walk, `true` otherwise.
+//
+func (elements *HtmlElements) Walk(walker HtmlNodeWalker) bool {
+	context := &WalkContext{
+		Ancestors: make([]*HtmlNode, 0),
 	}
 
-	for _, node := range doc.nodes {
-		shouldContinue := node.Traverse(visitor)
-		if !shouldContinue {
-			break
+	for index := 0; index < len(elements.list().nodes); {
+		node := elements.list().nodes[index]
+		following := followingNode(elements.list().nodes, index)
+		if !walker.walk(node, context) {
+			return false
 		}
+
+		index = nextIndex(elements.list().nodes, index, node, following)
 	}
+
+	return true
 }
 
 //
-// Allow traversing over the `HtmlNode`.
+// Walk over this node, and all its children, using the given walker.
+// The walker may modify the tree, see `HtmlElements.Walk()`.
 //
-func (node *HtmlNode) Traverse(visitor HtmlNodeVisitor) bool {
-	if visitor == nil {
-		return false
+// Returns `false` if the walker stopped the walk, `true` otherwise.
+//
+func (node *HtmlNode) Walk(walker HtmlNodeWalker) bool {
+	context := &WalkContext{
+		Ancestors: make([]*HtmlNode, 0),
 	}
 
-	shouldContinue := visitor(node)
-	if !shouldContinue {
+	return walker.walk(node, context)
+}
+
+//
+// Walk the given node with the given context, and return `false`
+// if the walk was stopped.
+//
+func (walker HtmlNodeWalker) walk(node *HtmlNode, context *WalkContext) bool {
+	parent := node._parent
+	elements := node._wrappingElements
+
+	action := WalkContinue
+	if walker.Enter != nil {
+		action = walker.Enter(node, context)
+	}
+
+	if action == WalkStop {
 		return false
 	}
 
-	if !node.HasChildren() {
-		return true
+	// the children of a node that was removed, or replaced,
+	// when it was entered are no more part of the tree
+	if node._parent != parent || node._wrappingElements != elements {
+		action = WalkSkipChildren
 	}
 
-	for _, child := range node._children {
-		shouldContinue := child.Traverse(visitor)
-		if !shouldContinue {
-			return false
+	if action != WalkSkipChildren && node.HasChildren() {
+		context.Ancestors = append(context.Ancestors, node)
+		context.Depth++
+
+		// the walker may modify the children, so we look up
+		// where the visited child is now before moving on
+		for index := 0; index < len(node._children); {
+			child := node._children[index]
+			following := followingNode(node._children, index)
+			if !walker.walk(child, context) {
+				return false
+			}
+
+			index = nextIndex(node._children, index, child, following)
 		}
+
+		context.Ancestors = context.Ancestors[:len(context.Ancestors)-1]
+		context.Depth--
+	}
+
+	if walker.Leave != nil && walker.Leave(node, context) == WalkStop {
+		return false
 	}
 
 	return true
 }
+
+//
+// Return a walker that calls the given visitor when a node is
+// entered, and stops if the visitor returns `false`.
+//
+func (visitor HtmlNodeVisitor) walker() HtmlNodeWalker {
+	return HtmlNodeWalker{
+		Enter: func(node *HtmlNode, context *WalkContext) WalkAction {
+			if visitor(node) {
+				return WalkContinue
+			}
+
+			return WalkStop
+		},
+	}
+}
+
+//
+// Allow traversing over the `HtmlDocument`. If a `nil`
+// visitor is supplied, no tree traversal happens.
+//
+func (doc *HtmlElements) Traverse(visitor HtmlNodeVisitor) {
+	if visitor == nil {
+		return
+	}
+
+	doc.Walk(visitor.walker())
+}
+
+//
+// Allow traversing over the `HtmlNode`.
+//
+func (node *HtmlNode) Traverse(visitor HtmlNodeVisitor) bool {
+	if visitor == nil {
+		return false
+	}
+
+	return node.Walk(visitor.walker())
+}
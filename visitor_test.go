@@ -12,6 +12,7 @@
 package lhtml
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -49,7 +50,7 @@ func TestNilVisitorNode(t *testing.T) {
 	doc, err := ParseHtmlString("<html><head><title>Hello world</title></head><body><div>Hello world</div></body></html>")
 	assert.NoError(t, err)
 
-	assert.False(t, doc.nodes[0]._children[0].Traverse(nil))
+	assert.False(t, doc.Nodes()[0]._children[0].Traverse(nil))
 }
 
 func TestTraverseDoc(t *testing.T) {
@@ -131,3 +132,182 @@ func TestTraverseNodeBreak(t *testing.T) {
 	doc.Traverse(visitor)
 	assert.Equal(t, " html head", s)
 }
+
+func TestWalkEnterLeave(t *testing.T) {
+	doc, err := ParseHtmlString("<html><head><title>Hello</title></head><body><div>World</div></body></html>")
+	assert.NoError(t, err)
+
+	events := make([]string, 0)
+	walker := HtmlNodeWalker{
+		Enter: func(node *HtmlNode, context *WalkContext) WalkAction {
+			if node.NodeType == ElementNode {
+				events = append(events, "+"+node.NodeName())
+			}
+			return WalkContinue
+		},
+		Leave: func(node *HtmlNode, context *WalkContext) WalkAction {
+			if node.NodeType == ElementNode {
+				events = append(events, "-"+node.NodeName())
+			}
+			return WalkContinue
+		},
+	}
+
+	assert.True(t, doc.Walk(walker))
+	assert.Equal(t, []string{"+html", "+head", "+title", "-title", "-head", "+body", "+div", "-div", "-body", "-html"}, events)
+
+	// a walker without functions just walks
+	assert.True(t, doc.Walk(HtmlNodeWalker{}))
+}
+
+func TestWalkSkipChildren(t *testing.T) {
+	doc, err := ParseHtmlString("<div><script><b>x</b></script><p>y</p></div>")
+	assert.NoError(t, err)
+
+	events := make([]string, 0)
+	walker := HtmlNodeWalker{
+		Enter: func(node *HtmlNode, context *WalkContext) WalkAction {
+			events = append(events, "+"+node.NodeName())
+			if node.NodeName() == "script" {
+				return WalkSkipChildren
+			}
+			return WalkContinue
+		},
+		Leave: func(node *HtmlNode, context *WalkContext) WalkAction {
+			events = append(events, "-"+node.NodeName())
+			return WalkContinue
+		},
+	}
+
+	assert.True(t, doc.Walk(walker))
+	assert.Equal(t, []string{"+div", "+script", "-script", "+p", "+", "-", "-p", "-div"}, events)
+}
+
+func TestWalkStop(t *testing.T) {
+	doc, err := ParseHtmlString("<div><p>a</p><p>b</p></div><span></span>")
+	assert.NoError(t, err)
+
+	// stop when leaving
+	events := make([]string, 0)
+	walker := HtmlNodeWalker{
+		Enter: func(node *HtmlNode, context *WalkContext) WalkAction {
+			events = append(events, "+"+node.NodeName())
+			return WalkContinue
+		},
+		Leave: func(node *HtmlNode, context *WalkContext) WalkAction {
+			events = append(events, "-"+node.NodeName())
+			if node.NodeName() == "p" {
+				return WalkStop
+			}
+			return WalkContinue
+		},
+	}
+
+	assert.False(t, doc.Walk(walker))
+	assert.Equal(t, []string{"+div", "+p", "+", "-", "-p"}, events)
+
+	// stop when entering, the node is never left
+	events = make([]string, 0)
+	walker.Enter = func(node *HtmlNode, context *WalkContext) WalkAction {
+		events = append(events, "+"+node.NodeName())
+		if node.NodeName() == "p" {
+			return WalkStop
+		}
+		return WalkContinue
+	}
+
+	assert.False(t, doc.First().Walk(walker))
+	assert.Equal(t, []string{"+div", "+p"}, events)
+}
+
+func TestWalkContext(t *testing.T) {
+	doc, err := ParseHtmlString("<html><body><div><p>text</p></div></body></html>")
+	assert.NoError(t, err)
+
+	paths := make([]string, 0)
+	walker := HtmlNodeWalker{
+		Enter: func(node *HtmlNode, context *WalkContext) WalkAction {
+			names := make([]string, 0)
+			for _, ancestor := range context.Ancestors {
+				names = append(names, ancestor.NodeName())
+			}
+			assert.Equal(t, len(context.Ancestors), context.Depth)
+			if context.Depth > 0 {
+				assert.Equal(t, node.Parent(), context.Parent())
+			} else {
+				assert.Nil(t, context.Parent())
+			}
+
+			paths = append(paths, strings.Join(append(names, node.NodeName()), "/"))
+			return WalkContinue
+		},
+	}
+
+	doc.Walk(walker)
+	assert.Equal(t, []string{"html", "html/body", "html/body/div", "html/body/div/p", "html/body/div/p/"}, paths)
+
+	// depth is relative to where the walk started
+	paths = make([]string, 0)
+	doc.GetElementsByName("div").First().Walk(walker)
+	assert.Equal(t, []string{"div", "div/p", "div/p/"}, paths)
+}
+
+func TestTraverseWithRemoval(t *testing.T) {
+	doc, err := ParseHtmlString("<ul><li>1</li><li class='x'>2</li><li class='x'>3</li><li>4</li></ul>")
+	assert.NoError(t, err)
+
+	visited := make([]string, 0)
+	doc.Traverse(func(node *HtmlNode) bool {
+		if node.NodeType == TextNode {
+			visited = append(visited, node.Data)
+		}
+		if node.HasAttribute("class") {
+			node.RemoveMe()
+		}
+		return true
+	})
+
+	// the children of removed nodes are not walked, but no sibling is skipped
+	assert.Equal(t, []string{"1", "4"}, visited)
+	assert.Equal(t, "<ul><li>1</li><li>4</li></ul>", doc.First().String())
+}
+
+func TestTraverseWithReplacement(t *testing.T) {
+	doc, err := ParseHtmlString("<ul><li>1</li><li class='x'>2</li><li>3</li></ul><li class='x'>4</li>")
+	assert.NoError(t, err)
+
+	visited := make([]string, 0)
+	doc.Traverse(func(node *HtmlNode) bool {
+		if node.NodeType == TextNode {
+			visited = append(visited, node.Data)
+		}
+		if node.HasAttribute("class") {
+			// the replacement has the same name, and is not visited again
+			node.ReplaceMe(El("li").Children(NewText("new")).Build())
+		}
+		return true
+	})
+
+	// neither the replacements nor the children of replaced nodes are walked
+	assert.Equal(t, []string{"1", "3"}, visited)
+	assert.Equal(t, "<ul><li>1</li><li>new</li><li>3</li></ul>", doc.First().String())
+	assert.Equal(t, "<li>new</li>", doc.Get(1).String())
+}
+
+func TestTraverseWithInsertion(t *testing.T) {
+	doc, err := ParseHtmlString("<div><p>a</p><p>b</p></div>")
+	assert.NoError(t, err)
+
+	called := 0
+	doc.Traverse(func(node *HtmlNode) bool {
+		called++
+		if node.NodeName() == "p" && node.First().Data == "a" {
+			node.InsertAfterMe(&HtmlNode{NodeType: TextNode, Data: "inserted"})
+		}
+		return true
+	})
+
+	// div, p, a, inserted, p, b
+	assert.Equal(t, 6, called)
+	assert.Equal(t, "<div><p>a</p>inserted<p>b</p></div>", doc.First().String())
+}